@@ -0,0 +1,241 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-tools/pkg/webhook"
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "webhookgen",
+		Short: "A generation tool for admission webhook configurations.",
+		Long:  `A generation tool for admission webhook configurations.`,
+		Example: `	# Generate webhook manifests from a config file
+	webhookgen generate --config-file webhookgen.yaml --output-dir config/webhooks
+`,
+	}
+
+	rootCmd.AddCommand(
+		newGenerateCmd(),
+	)
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+type generateOptions struct {
+	configFile       string
+	outputDir        string
+	targetAPIVersion string
+	dryRun           bool
+	validateOnly     bool
+	diff             bool
+	watch            bool
+}
+
+func newGenerateCmd() *cobra.Command {
+	o := &generateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generates webhook configuration manifests",
+		Long: `Generate webhook configuration manifests from a YAML generator options file.
+Usage:
+# webhookgen generate --config-file config_file [--output-dir output_dir] [--dry-run]
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := o.run(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&o.configFile, "config-file", "", "YAML file containing the generator options.")
+	f.StringVar(&o.outputDir, "output-dir", ".", "output directory where generated manifests will be saved.")
+	f.StringVar(&o.targetAPIVersion, "target-api-version", "", "target Kubernetes version, e.g. 1.14, that the generated manifests must apply cleanly to.")
+	f.BoolVar(&o.dryRun, "dry-run", false, "print the generated manifests to stdout instead of writing files.")
+	f.BoolVar(&o.validateOnly, "validate-only", false, "validate the webhook configuration without writing files.")
+	f.BoolVar(&o.diff, "diff", false, "show a diff against the existing output files and exit non-zero if they are stale, without writing files.")
+	f.BoolVar(&o.watch, "watch", false, "keep running and regenerate whenever the config file changes.")
+	cmd.MarkFlagRequired("config-file") // nolint: errcheck
+	return cmd
+}
+
+// watchQuietPeriod is how long the watch mode waits after a config file
+// change before regenerating, so rapid successive saves only regenerate once.
+const watchQuietPeriod = 500 * time.Millisecond
+
+func (o *generateOptions) run() error {
+	if len(o.configFile) == 0 {
+		return fmt.Errorf("--config-file should be set")
+	}
+	if err := o.generateOnce(); err != nil {
+		return err
+	}
+	if !o.watch {
+		return nil
+	}
+	fmt.Printf("watching '%s' for changes\n", o.configFile)
+	// Regeneration errors are logged instead of returned, so a config edit
+	// that doesn't parse yet won't kill the watch session.
+	return webhook.WatchConfigWithDebounce(context.Background(), o.configFile, watchQuietPeriod, func() error {
+		if err := o.generateOnce(); err != nil {
+			log.Printf("regeneration failed: %v", err)
+		}
+		return nil
+	})
+}
+
+// generateOnce loads the config file, generates the objects, and writes (or
+// prints) them. It prints a summary of the output files that changed.
+func (o *generateOptions) generateOnce() error {
+	opts, err := webhook.LoadFromFile(o.configFile)
+	if err != nil {
+		return err
+	}
+	if len(o.targetAPIVersion) != 0 {
+		webhook.WithTargetK8sVersion(o.targetAPIVersion)(opts)
+	}
+	if o.validateOnly {
+		errs := opts.ValidateWebhooks()
+		if len(errs) == 0 {
+			fmt.Println("webhook configuration is valid")
+			return nil
+		}
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return fmt.Errorf("%d validation error(s)", len(errs))
+	}
+	objects, err := opts.Generate()
+	if err != nil {
+		return err
+	}
+	if o.dryRun {
+		return printObjects(objects)
+	}
+	if o.diff {
+		return diffObjects(o.outputDir, objects)
+	}
+	changed, err := writeObjects(o.outputDir, objects)
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		fmt.Printf("webhook manifests under '%s' are up to date\n", o.outputDir)
+		return nil
+	}
+	for _, fileName := range changed {
+		fmt.Printf("wrote %s\n", fileName)
+	}
+	return nil
+}
+
+// printObjects prints the objects to stdout as a multi-document YAML stream.
+func printObjects(objects []runtime.Object) error {
+	for i := range objects {
+		content, err := yaml.Marshal(objects[i])
+		if err != nil {
+			return err
+		}
+		fmt.Println("---")
+		fmt.Print(string(content))
+	}
+	return nil
+}
+
+// writeObjects writes each object to <output-dir>/<Kind>-<Name>.yaml and
+// returns the files whose content changed. Files that already hold the
+// generated content are left untouched.
+func writeObjects(outputDir string, objects []runtime.Object) ([]string, error) {
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return nil, err
+	}
+	var changed []string
+	for i := range objects {
+		fileName, content, err := renderObject(outputDir, objects[i])
+		if err != nil {
+			return nil, err
+		}
+		if existing, err := ioutil.ReadFile(fileName); err == nil && bytes.Equal(existing, content) {
+			continue
+		}
+		if err := ioutil.WriteFile(fileName, content, 0600); err != nil {
+			return nil, err
+		}
+		changed = append(changed, fileName)
+	}
+	return changed, nil
+}
+
+// diffObjects prints a diff between the existing output files and the
+// generated objects without writing anything. It returns an error if any
+// file is stale, so CI can fail on uncommitted generated changes.
+func diffObjects(outputDir string, objects []runtime.Object) error {
+	stale := 0
+	for i := range objects {
+		fileName, content, err := renderObject(outputDir, objects[i])
+		if err != nil {
+			return err
+		}
+		existing, err := ioutil.ReadFile(fileName)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		diff := webhook.DiffText(string(existing), string(content))
+		if len(diff) == 0 {
+			continue
+		}
+		stale++
+		fmt.Printf("--- %s\n+++ %s (generated)\n%s", fileName, fileName, diff)
+	}
+	if stale != 0 {
+		return fmt.Errorf("%d generated file(s) are stale", stale)
+	}
+	return nil
+}
+
+// renderObject serializes the object and computes its output file name.
+func renderObject(outputDir string, obj runtime.Object) (string, []byte, error) {
+	content, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", nil, err
+	}
+	accessor, ok := obj.(metav1.Object)
+	if !ok {
+		return "", nil, fmt.Errorf("generated object %T has no object metadata", obj)
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	return filepath.Join(outputDir, fmt.Sprintf("%s-%s.yaml", kind, accessor.GetName())), content, nil
+}
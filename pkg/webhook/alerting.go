@@ -0,0 +1,140 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// alertingRuleName is the name of the generated PrometheusRule object.
+const alertingRuleName = "webhook-alert-rules"
+
+// AlertingOptions configures the generation of Prometheus alerting rules for
+// fail-closed webhooks. An unavailable fail-closed webhook blocks all
+// admission requests it matches, so its failure must page someone.
+type AlertingOptions struct {
+	// Provider is the alert routing target, e.g. pagerduty or opsgenie.
+	// It is set as a label on every alert so the alertmanager configuration
+	// can route on it.
+	Provider string
+	// Severity is set as the severity label on every alert, e.g. critical.
+	Severity string
+	// RunbookURL is set as the runbook_url annotation on every alert.
+	RunbookURL string
+}
+
+// WithAlerting enables generating a PrometheusRule with alerts for the
+// fail-closed webhooks.
+func WithAlerting(a AlertingOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.alerting = &a
+	}
+}
+
+// prometheusRule creates the PrometheusRule object with a latency and an
+// error-rate alert per fail-closed webhook. It returns nil if alerting is not
+// configured or no registered webhook is fail-closed.
+func (o *generatorOptions) prometheusRule() runtime.Object {
+	if o.alerting == nil {
+		return nil
+	}
+	var rules []monitoringv1.Rule
+	for _, name := range o.failClosedWebhookNames() {
+		rules = append(rules, o.alerting.alertsFor(name)...)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	namespace := ""
+	if o.service != nil {
+		namespace = o.service.Namespace
+	}
+	return &monitoringv1.PrometheusRule{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: monitoringv1.SchemeGroupVersion.String(),
+			Kind:       monitoringv1.PrometheusRuleKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      alertingRuleName,
+			Namespace: namespace,
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{
+					Name:  "webhook.rules",
+					Rules: rules,
+				},
+			},
+		},
+	}
+}
+
+// failClosedWebhookNames returns the sorted names of the enabled registered
+// webhooks with FailurePolicy Fail.
+func (o *generatorOptions) failClosedWebhookNames() []string {
+	var names []string
+	for _, wh := range o.registry {
+		if wh.disabled() {
+			continue
+		}
+		if wh.FailurePolicy == nil || *wh.FailurePolicy != admissionregistration.Fail {
+			continue
+		}
+		names = append(names, wh.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// alertsFor builds the latency and error-rate alerts for the named webhook.
+// The webhook name is set as a label on both alerts for routing.
+func (a *AlertingOptions) alertsFor(name string) []monitoringv1.Rule {
+	labels := map[string]string{
+		"webhook":  name,
+		"provider": a.Provider,
+		"severity": a.Severity,
+	}
+	var annotations map[string]string
+	if len(a.RunbookURL) != 0 {
+		annotations = map[string]string{"runbook_url": a.RunbookURL}
+	}
+	return []monitoringv1.Rule{
+		{
+			Alert: "WebhookHighLatency",
+			Expr: intstr.FromString(fmt.Sprintf(
+				"histogram_quantile(0.99, sum(rate(webhook_admission_duration_seconds_bucket{webhook=%q}[5m])) by (le)) > 5", name)),
+			For:         "5m",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		{
+			Alert: "WebhookHighErrorRate",
+			Expr: intstr.FromString(fmt.Sprintf(
+				"sum(rate(webhook_admission_requests_total{webhook=%q,code=~\"5..\"}[5m])) / sum(rate(webhook_admission_requests_total{webhook=%q}[5m])) > 0.01", name, name)),
+			For:         "5m",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
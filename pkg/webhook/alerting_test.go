@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+func TestGeneratePrometheusRule(t *testing.T) {
+	failurePolicyFail := admissionregistration.Fail
+	failClosed := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	failClosed.FailurePolicy = &failurePolicyFail
+	// fail-open webhooks get no alerts
+	failOpen := testValidatingWebhook("auditpods.example.com", "/audit-pods")
+
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithAlerting(AlertingOptions{
+			Provider:   "pagerduty",
+			Severity:   "critical",
+			RunbookURL: "https://runbooks.example.com/webhook-down",
+		}),
+		WithWebhook(failClosed, failOpen),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	var rule *monitoringv1.PrometheusRule
+	for _, obj := range objects {
+		if typed, ok := obj.(*monitoringv1.PrometheusRule); ok {
+			rule = typed
+		}
+	}
+	if rule == nil {
+		t.Fatal("expected a PrometheusRule to be generated")
+	}
+	if rule.Namespace != "test-system" {
+		t.Errorf("expected the rule namespace to default to the service namespace, got %q", rule.Namespace)
+	}
+	if len(rule.Spec.Groups) != 1 {
+		t.Fatalf("expected 1 rule group, got %d", len(rule.Spec.Groups))
+	}
+	alerts := rule.Spec.Groups[0].Rules
+	// one latency and one error-rate alert for the single fail-closed webhook
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %+v", alerts)
+	}
+	for _, alert := range alerts {
+		if alert.Labels["webhook"] != failClosed.Name {
+			t.Errorf("expected the webhook name label for routing, got %v", alert.Labels)
+		}
+		if alert.Labels["provider"] != "pagerduty" || alert.Labels["severity"] != "critical" {
+			t.Errorf("unexpected alert labels: %v", alert.Labels)
+		}
+		if alert.Annotations["runbook_url"] != "https://runbooks.example.com/webhook-down" {
+			t.Errorf("unexpected alert annotations: %v", alert.Annotations)
+		}
+		if !strings.Contains(alert.Expr.StrVal, failClosed.Name) {
+			t.Errorf("expected the expression to select the webhook metrics, got %q", alert.Expr.StrVal)
+		}
+	}
+}
+
+func TestGenerateNoPrometheusRuleForFailOpen(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithAlerting(AlertingOptions{Provider: "opsgenie", Severity: "warning"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*monitoringv1.PrometheusRule); ok {
+			t.Error("expected no PrometheusRule when no webhook is fail-closed")
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WithAllowedClientCIDRs restricts the webhook server to connections from
+// the given CIDRs, e.g. the kube-apiserver address range, so arbitrary pods
+// in the cluster can't probe it. The restriction is enforced by
+// AllowClientCIDRs, not by the generated configuration.
+func WithAllowedClientCIDRs(cidrs ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.allowedClientCIDRs = cidrs
+	}
+}
+
+// AllowClientCIDRs wraps the handler with middleware rejecting connections
+// whose source address is outside the configured client CIDRs. Without
+// configured CIDRs the handler is returned unchanged.
+func (o *generatorOptions) AllowClientCIDRs(handler http.Handler) (http.Handler, error) {
+	if len(o.allowedClientCIDRs) == 0 {
+		return handler, nil
+	}
+	cidrs := make([]net.IPNet, 0, len(o.allowedClientCIDRs))
+	for _, cidr := range o.allowedClientCIDRs {
+		_, parsed, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client CIDR %q: %v", cidr, err)
+		}
+		cidrs = append(cidrs, *parsed)
+	}
+	return cidrAllowlistMiddleware(cidrs, handler), nil
+}
+
+// cidrAllowlistMiddleware rejects requests whose remote address is not in
+// one of the given CIDRs with HTTP 403.
+func cidrAllowlistMiddleware(cidrs []net.IPNet, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			// RemoteAddr may come without a port, e.g. from a proxy.
+			host = req.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || !cidrsContain(cidrs, ip) {
+			http.Error(rw, fmt.Sprintf("client address %q is not allowed", req.RemoteAddr), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// cidrsContain returns true if any of the CIDRs contains the IP.
+func cidrsContain(cidrs []net.IPNet, ip net.IP) bool {
+	for i := range cidrs {
+		if cidrs[i].Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
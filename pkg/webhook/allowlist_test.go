@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAllowClientCIDRs(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithAllowedClientCIDRs("10.0.0.0/24", "192.168.1.0/24"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	handled := false
+	handler, err := o.AllowClientCIDRs(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		handled = true
+	}))
+	if err != nil {
+		t.Fatalf("expected AllowClientCIDRs to succeed, got %v", err)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		allowed    bool
+	}{
+		{"10.0.0.7:54321", true},
+		{"192.168.1.200:443", true},
+		{"10.0.1.7:54321", false},
+		{"172.16.0.1:443", false},
+		{"not-an-ip:443", false},
+	}
+	for _, test := range tests {
+		handled = false
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/mutate-pods", nil)
+		req.RemoteAddr = test.remoteAddr
+		handler.ServeHTTP(recorder, req)
+		if test.allowed {
+			if !handled || recorder.Code != http.StatusOK {
+				t.Errorf("expected %q to be allowed, got status %d", test.remoteAddr, recorder.Code)
+			}
+		} else {
+			if handled || recorder.Code != http.StatusForbidden {
+				t.Errorf("expected %q to be rejected with 403, got status %d", test.remoteAddr, recorder.Code)
+			}
+		}
+	}
+}
+
+func TestAllowClientCIDRsUnrestricted(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	inner := http.NotFoundHandler()
+	handler, err := o.AllowClientCIDRs(inner)
+	if err != nil {
+		t.Fatalf("expected AllowClientCIDRs to succeed, got %v", err)
+	}
+	// The handlers are HandlerFuncs, so compare the function pointers
+	// instead of the uncomparable interface values.
+	if reflect.ValueOf(handler).Pointer() != reflect.ValueOf(inner).Pointer() {
+		t.Error("expected the handler to be returned unchanged without CIDRs")
+	}
+}
+
+func TestAllowClientCIDRsInvalid(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithAllowedClientCIDRs("10.0.0.0/40"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if _, err := o.AllowClientCIDRs(http.NotFoundHandler()); err == nil {
+		t.Error("expected an invalid CIDR to fail")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	argocdv1alpha1 "github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// argoCDApplicationName is the name of the generated ArgoCD Application.
+const argoCDApplicationName = "webhook-manifests"
+
+// argoCDDefaultServer is the in-cluster API server ArgoCD deploys to when no
+// other destination is configured.
+const argoCDDefaultServer = "https://kubernetes.default.svc"
+
+// ArgoCDOptions configures the generation of an ArgoCD Application that
+// deploys the generated webhook manifests from git.
+type ArgoCDOptions struct {
+	// Project is the ArgoCD project the Application belongs to.
+	// Defaults to "default".
+	Project string
+	// RepoURL is the git repository URL holding the generated manifests.
+	RepoURL string
+	// TargetRevision is the revision to sync to. Defaults to HEAD.
+	TargetRevision string
+	// DestinationNamespace is the namespace the manifests are deployed to.
+	DestinationNamespace string
+	// OutputPath is the directory within the repository holding the
+	// generated manifests, e.g. the WriteManifestDir target.
+	OutputPath string
+}
+
+// WithArgoCD enables generating an ArgoCD Application for the webhook
+// manifests.
+func WithArgoCD(a ArgoCDOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.argocd = &a
+	}
+}
+
+// argoCDApplication creates the ArgoCD Application pointing at the generated
+// manifests, with automated sync and self-healing enabled so drift in the
+// cluster is corrected from git. It returns nil if ArgoCD generation is not
+// configured.
+func (o *generatorOptions) argoCDApplication() runtime.Object {
+	if o.argocd == nil {
+		return nil
+	}
+	project := o.argocd.Project
+	if len(project) == 0 {
+		project = "default"
+	}
+	targetRevision := o.argocd.TargetRevision
+	if len(targetRevision) == 0 {
+		targetRevision = "HEAD"
+	}
+	return &argocdv1alpha1.Application{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: argocdv1alpha1.SchemeGroupVersion.String(),
+			Kind:       argocdv1alpha1.ApplicationKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: argoCDApplicationName,
+		},
+		Spec: argocdv1alpha1.ApplicationSpec{
+			Project: project,
+			Source: argocdv1alpha1.ApplicationSource{
+				RepoURL:        o.argocd.RepoURL,
+				Path:           o.argocd.OutputPath,
+				TargetRevision: targetRevision,
+			},
+			Destination: argocdv1alpha1.ApplicationDestination{
+				Server:    argoCDDefaultServer,
+				Namespace: o.argocd.DestinationNamespace,
+			},
+			SyncPolicy: &argocdv1alpha1.SyncPolicy{
+				Automated: &argocdv1alpha1.SyncPolicyAutomated{
+					SelfHeal: true,
+				},
+			},
+		},
+	}
+}
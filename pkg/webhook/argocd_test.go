@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	argocdv1alpha1 "github.com/argoproj/argo-cd/pkg/apis/application/v1alpha1"
+)
+
+func TestGenerateArgoCDApplication(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{Name: "svc", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithArgoCD(ArgoCDOptions{
+			RepoURL:              "https://github.com/example/deploy.git",
+			DestinationNamespace: "system",
+			OutputPath:           "manifests/webhooks",
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	var app *argocdv1alpha1.Application
+	for _, obj := range objects {
+		if typed, ok := obj.(*argocdv1alpha1.Application); ok {
+			app = typed
+		}
+	}
+	if app == nil {
+		t.Fatal("expected an ArgoCD Application to be generated")
+	}
+	if app.Spec.Project != "default" {
+		t.Errorf("expected the project to default to %q, got %q", "default", app.Spec.Project)
+	}
+	if app.Spec.Source.RepoURL != "https://github.com/example/deploy.git" ||
+		app.Spec.Source.Path != "manifests/webhooks" ||
+		app.Spec.Source.TargetRevision != "HEAD" {
+		t.Errorf("unexpected application source: %+v", app.Spec.Source)
+	}
+	if app.Spec.Destination.Namespace != "system" {
+		t.Errorf("unexpected destination: %+v", app.Spec.Destination)
+	}
+	if app.Spec.SyncPolicy == nil || app.Spec.SyncPolicy.Automated == nil || !app.Spec.SyncPolicy.Automated.SelfHeal {
+		t.Errorf("expected automated sync with selfHeal, got %+v", app.Spec.SyncPolicy)
+	}
+}
+
+func TestGenerateNoArgoCDApplicationByDefault(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*argocdv1alpha1.Application); ok {
+			t.Error("expected no Application without ArgoCD options")
+		}
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// CertProvisioner provisions the serving certificate of the webhook server.
+// Provision ensures a valid certificate and key exist as tls.crt and tls.key
+// in certDir and returns the PEM-encoded CA certificate, which Generate
+// embeds as the CABundle of the generated client configs.
+type CertProvisioner interface {
+	Provision(ctx context.Context, certDir string) ([]byte, error)
+}
+
+// WithCertProvisioner runs the given provisioner during Generate when no CA
+// bundle is configured, so the generated configurations carry the CA of the
+// freshly provisioned serving certificate.
+func WithCertProvisioner(p CertProvisioner) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.provisioner = p
+	}
+}
+
+// AWSACMOptions configures certificate provisioning through AWS ACM
+// Private CA.
+type AWSACMOptions struct {
+	// CertificateAuthorityArn is the ARN of the ACM private CA issuing the
+	// certificate.
+	CertificateAuthorityArn string
+	// Region is the AWS region of the CA.
+	Region string
+	// RenewBefore is how long before expiry an existing certificate is
+	// reissued. An existing certificate valid for longer is left in place.
+	RenewBefore time.Duration
+}
+
+// ACMClient is the subset of the AWS ACM Private CA API the provisioner
+// uses. The aws-sdk-go-v2 acmpca client satisfies it through a thin adapter;
+// the SDK itself is not vendored here, so non-AWS builds don't carry it.
+type ACMClient interface {
+	// IssueCertificate submits the PEM-encoded CSR to the CA and returns
+	// the ARN of the certificate being issued.
+	IssueCertificate(ctx context.Context, caArn string, csr []byte) (string, error)
+	// GetCertificate fetches the issued certificate and its chain. It
+	// returns an error while issuance is still in progress.
+	GetCertificate(ctx context.Context, caArn, certificateArn string) (cert, chain []byte, err error)
+	// GetCertificateAuthorityCertificate fetches the PEM-encoded CA
+	// certificate.
+	GetCertificateAuthorityCertificate(ctx context.Context, caArn string) ([]byte, error)
+}
+
+// Defaults for polling ACM for issuance. Private CA issuance typically
+// completes within seconds.
+const (
+	defaultACMPollInterval = 2 * time.Second
+	defaultACMPollTimeout  = 5 * time.Minute
+)
+
+// ACMCertProvisioner provisions the webhook serving certificate from AWS ACM
+// Private CA: it generates a key and CSR, requests a certificate, polls for
+// issuance and writes the PEM-encoded certificate and key to certDir.
+type ACMCertProvisioner struct {
+	// Options configure the CA to issue from.
+	Options AWSACMOptions
+	// Client talks to ACM Private CA.
+	Client ACMClient
+	// DNSNames are the subject alternative names of the certificate,
+	// e.g. <service>.<namespace>.svc.
+	DNSNames []string
+	// PollInterval and PollTimeout bound the wait for issuance. Zero values
+	// use the defaults.
+	PollInterval time.Duration
+	PollTimeout  time.Duration
+}
+
+// Provision implements CertProvisioner. An existing certificate in certDir
+// still valid for longer than RenewBefore is left in place; only the CA
+// certificate is fetched then.
+func (p *ACMCertProvisioner) Provision(ctx context.Context, certDir string) ([]byte, error) {
+	if p.Client == nil {
+		return nil, errors.New("ACM provisioning requires a client")
+	}
+	if len(p.Options.CertificateAuthorityArn) == 0 {
+		return nil, errors.New("ACM provisioning requires a certificate authority ARN")
+	}
+	if len(p.DNSNames) == 0 {
+		return nil, errors.New("ACM provisioning requires at least one DNS name")
+	}
+	caArn := p.Options.CertificateAuthorityArn
+	if !p.needsIssuance(certDir) {
+		return p.Client.GetCertificateAuthorityCertificate(ctx, caArn)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the serving key: %v", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		DNSNames: p.DNSNames,
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CSR: %v", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})
+
+	certificateArn, err := p.Client.IssueCertificate(ctx, caArn, csrPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request a certificate from %s: %v", caArn, err)
+	}
+	cert, chain, err := p.waitForIssuance(ctx, caArn, certificateArn)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	certPEM := append(append([]byte{}, cert...), chain...)
+	if err := ioutil.WriteFile(filepath.Join(certDir, "tls.crt"), certPEM, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write the certificate: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(certDir, "tls.key"), keyPEM, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write the key: %v", err)
+	}
+	return p.Client.GetCertificateAuthorityCertificate(ctx, caArn)
+}
+
+// needsIssuance reports whether a new certificate has to be issued: there is
+// none in certDir, it does not parse, or it expires within RenewBefore.
+func (p *ACMCertProvisioner) needsIssuance(certDir string) bool {
+	content, err := ioutil.ReadFile(filepath.Join(certDir, "tls.crt"))
+	if err != nil {
+		return true
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	return time.Now().Add(p.Options.RenewBefore).After(cert.NotAfter)
+}
+
+// waitForIssuance polls GetCertificate until the certificate is issued or
+// the timeout expires.
+func (p *ACMCertProvisioner) waitForIssuance(ctx context.Context, caArn, certificateArn string) ([]byte, []byte, error) {
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = defaultACMPollInterval
+	}
+	timeout := p.PollTimeout
+	if timeout <= 0 {
+		timeout = defaultACMPollTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for {
+		cert, chain, err := p.Client.GetCertificate(ctx, caArn, certificateArn)
+		if err == nil {
+			return cert, chain, nil
+		}
+		lastErr = err
+		if time.Now().Add(interval).After(deadline) {
+			return nil, nil, fmt.Errorf("certificate %s was not issued within %v: %v", certificateArn, timeout, lastErr)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+// fakeACMClient issues a fixed certificate after a configurable number of
+// polls.
+type fakeACMClient struct {
+	pollsUntilIssued int
+	polls            int
+	issuedCSR        []byte
+}
+
+func (f *fakeACMClient) IssueCertificate(ctx context.Context, caArn string, csr []byte) (string, error) {
+	f.issuedCSR = csr
+	return "arn:aws:acm-pca::cert/42", nil
+}
+
+func (f *fakeACMClient) GetCertificate(ctx context.Context, caArn, certificateArn string) ([]byte, []byte, error) {
+	f.polls++
+	if f.polls <= f.pollsUntilIssued {
+		return nil, nil, errors.New("certificate is pending")
+	}
+	return []byte("the-cert\n"), []byte("the-chain\n"), nil
+}
+
+func (f *fakeACMClient) GetCertificateAuthorityCertificate(ctx context.Context, caArn string) ([]byte, error) {
+	return []byte("the-ca-cert"), nil
+}
+
+func TestACMCertProvisioner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acm-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	client := &fakeACMClient{pollsUntilIssued: 2}
+	p := &ACMCertProvisioner{
+		Options:      AWSACMOptions{CertificateAuthorityArn: "arn:aws:acm-pca::ca/1"},
+		Client:       client,
+		DNSNames:     []string{"webhook-service.system.svc"},
+		PollInterval: time.Millisecond,
+		PollTimeout:  time.Second,
+	}
+	bundle, err := p.Provision(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("expected provisioning to succeed, got %v", err)
+	}
+	if string(bundle) != "the-ca-cert" {
+		t.Errorf("expected the CA certificate as bundle, got %q", bundle)
+	}
+	if !strings.Contains(string(client.issuedCSR), "CERTIFICATE REQUEST") {
+		t.Error("expected a PEM-encoded CSR to be submitted")
+	}
+	cert, err := ioutil.ReadFile(filepath.Join(dir, "tls.crt"))
+	if err != nil || string(cert) != "the-cert\nthe-chain\n" {
+		t.Errorf("expected the certificate and chain to be written, got %q, %v", cert, err)
+	}
+	key, err := ioutil.ReadFile(filepath.Join(dir, "tls.key"))
+	if err != nil || !strings.Contains(string(key), "RSA PRIVATE KEY") {
+		t.Errorf("expected a PEM-encoded key to be written, got %v", err)
+	}
+}
+
+func TestACMCertProvisionerTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acm-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	p := &ACMCertProvisioner{
+		Options:      AWSACMOptions{CertificateAuthorityArn: "arn:aws:acm-pca::ca/1"},
+		Client:       &fakeACMClient{pollsUntilIssued: 1000},
+		DNSNames:     []string{"webhook-service.system.svc"},
+		PollInterval: time.Millisecond,
+		PollTimeout:  5 * time.Millisecond,
+	}
+	if _, err := p.Provision(context.Background(), dir); err == nil {
+		t.Error("expected a pending certificate to time out")
+	}
+}
+
+func TestGenerateWithCertProvisioner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acm-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithCertDir(dir),
+		WithCertProvisioner(&ACMCertProvisioner{
+			Options:      AWSACMOptions{CertificateAuthorityArn: "arn:aws:acm-pca::ca/1"},
+			Client:       &fakeACMClient{},
+			DNSNames:     []string{"localhost"},
+			PollInterval: time.Millisecond,
+			PollTimeout:  time.Second,
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	cfg := objects[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if string(cfg.Webhooks[0].ClientConfig.CABundle) != "the-ca-cert" {
+		t.Errorf("expected the CABundle from the CA certificate, got %q", cfg.Webhooks[0].ClientConfig.CABundle)
+	}
+}
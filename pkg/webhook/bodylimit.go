@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultMaxRequestBodyBytes caps admission request bodies at 10MB when a
+// webhook doesn't set its own limit.
+const defaultMaxRequestBodyBytes int64 = 10 << 20
+
+// maxRequestBodyBytes returns the body limit of the webhook, defaulted.
+func (w *admissionWebhook) maxRequestBodyBytes() int64 {
+	if w.MaxRequestBodyBytes > 0 {
+		return w.MaxRequestBodyBytes
+	}
+	return defaultMaxRequestBodyBytes
+}
+
+// LimitRequestBody wraps the handler of the webhook registered at path with
+// middleware enforcing the webhook's MaxRequestBodyBytes: oversized requests
+// are rejected with a 413 response carrying a descriptive AdmissionResponse
+// instead of reaching the handler and risking an OOM.
+func (o *generatorOptions) LimitRequestBody(path string, handler http.Handler) (http.Handler, error) {
+	wh, found := o.registry[path]
+	if !found {
+		return nil, fmt.Errorf("no webhook is registered at path %q", path)
+	}
+	return limitRequestBody(handler, wh.maxRequestBodyBytes()), nil
+}
+
+// limitRequestBody reads the request body through http.MaxBytesReader before
+// invoking the handler, so the limit is enforced no matter how the handler
+// consumes the body.
+func limitRequestBody(handler http.Handler, limit int64) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Body != nil {
+			body, err := ioutil.ReadAll(http.MaxBytesReader(rw, req.Body, limit))
+			if err != nil {
+				writeBodyTooLarge(rw, limit)
+				return
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		handler.ServeHTTP(rw, req)
+	})
+}
+
+// writeBodyTooLarge writes a 413 response with an AdmissionResponse denying
+// the request, so the API server surfaces the reason to the client.
+func writeBodyTooLarge(rw http.ResponseWriter, limit int64) {
+	review := admissionv1beta1.AdmissionReview{
+		Response: &admissionv1beta1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("admission request body exceeds the webhook's limit of %d bytes", limit),
+			},
+		},
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusRequestEntityTooLarge)
+	// Encoding a static struct cannot fail; the error is deliberately
+	// ignored since the status code is already written.
+	json.NewEncoder(rw).Encode(review) // nolint: errcheck
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+func TestLimitRequestBody(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.MaxRequestBodyBytes = 16
+
+	o := NewGenerator(WithHost("localhost"), WithWebhook(wh))
+	handled := false
+	handler, err := o.LimitRequestBody("/mutate-pods", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		handled = true
+	}))
+	if err != nil {
+		t.Fatalf("expected LimitRequestBody to succeed, got %v", err)
+	}
+
+	// a request within the limit reaches the handler
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/mutate-pods", strings.NewReader("small body")))
+	if !handled {
+		t.Error("expected a request within the limit to reach the handler")
+	}
+
+	// an oversized request is rejected with 413 and a denying response
+	handled = false
+	recorder = httptest.NewRecorder()
+	oversized := strings.NewReader(strings.Repeat("x", 64))
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/mutate-pods", oversized))
+	if handled {
+		t.Error("expected an oversized request to be rejected before the handler")
+	}
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", recorder.Code)
+	}
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &review); err != nil {
+		t.Fatalf("expected an AdmissionReview response, got %v", err)
+	}
+	if review.Response == nil || review.Response.Allowed {
+		t.Errorf("expected a denying admission response, got %+v", review.Response)
+	}
+	if review.Response.Result == nil || !strings.Contains(review.Response.Result.Message, "16 bytes") {
+		t.Errorf("expected the limit in the response message, got %+v", review.Response.Result)
+	}
+}
+
+func TestLimitRequestBodyDefault(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	if wh.maxRequestBodyBytes() != defaultMaxRequestBodyBytes {
+		t.Errorf("expected the 10MB default, got %d", wh.maxRequestBodyBytes())
+	}
+}
+
+func TestLimitRequestBodyUnknownPath(t *testing.T) {
+	o := NewGenerator(WithHost("localhost"), WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	if _, err := o.LimitRequestBody("/unknown", http.NotFoundHandler()); err == nil {
+		t.Error("expected an unregistered path to fail")
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// caBundleSecretRef identifies the existing secret key Generate reads the CA
+// bundle from.
+type caBundleSecretRef struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	key       string
+}
+
+// WithCABundleFromSecret makes Generate fetch the CA bundle from the given
+// key of an existing secret when no bundle is configured otherwise. This
+// suits provisioning pipelines that store the CA in-cluster before the
+// webhook configurations are generated.
+func WithCABundleFromSecret(client kubernetes.Interface, namespace, name, key string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.caBundleSecret = &caBundleSecretRef{
+			client:    client,
+			namespace: namespace,
+			name:      name,
+			key:       key,
+		}
+	}
+}
+
+// CABundleFromSecret fetches the CA bundle from the given key of an existing
+// secret and validates that it is a PEM-encoded CA certificate.
+func CABundleFromSecret(ctx context.Context, client kubernetes.Interface, namespace, name, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %v", namespace, name, err)
+	}
+	bundle, found := secret.Data[key]
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s has no key %q", namespace, name, key)
+	}
+	if err := validateCABundle(bundle); err != nil {
+		return nil, fmt.Errorf("secret %s/%s key %q: %v", namespace, name, key, err)
+	}
+	return bundle, nil
+}
+
+// validateCABundle checks that the bundle holds a PEM-encoded CA certificate.
+func validateCABundle(bundle []byte) error {
+	block, _ := pem.Decode(bundle)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return errors.New("not a PEM-encoded certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse the certificate: %v", err)
+	}
+	if !cert.IsCA {
+		return errors.New("the certificate is not a CA certificate")
+	}
+	return nil
+}
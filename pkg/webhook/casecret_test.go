@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testCAPEM returns a freshly generated self-signed CA certificate in PEM.
+func testCAPEM(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create the CA certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func caSecret(data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "system", Name: "webhook-ca"},
+		Data:       data,
+	}
+}
+
+func TestCABundleFromSecret(t *testing.T) {
+	caPEM := testCAPEM(t)
+	client := fake.NewSimpleClientset(caSecret(map[string][]byte{"ca.crt": caPEM}))
+	bundle, err := CABundleFromSecret(context.Background(), client, "system", "webhook-ca", "ca.crt")
+	if err != nil {
+		t.Fatalf("expected CABundleFromSecret to succeed, got %v", err)
+	}
+	if string(bundle) != string(caPEM) {
+		t.Error("expected the bundle from the secret to be returned unmodified")
+	}
+
+	if _, err := CABundleFromSecret(context.Background(), client, "system", "webhook-ca", "missing"); err == nil {
+		t.Error("expected a missing key to fail")
+	}
+	if _, err := CABundleFromSecret(context.Background(), client, "system", "no-such-secret", "ca.crt"); err == nil {
+		t.Error("expected a missing secret to fail")
+	}
+}
+
+func TestCABundleFromSecretValidation(t *testing.T) {
+	client := fake.NewSimpleClientset(caSecret(map[string][]byte{"ca.crt": []byte("not a cert")}))
+	if _, err := CABundleFromSecret(context.Background(), client, "system", "webhook-ca", "ca.crt"); err == nil {
+		t.Error("expected a non-PEM value to fail validation")
+	}
+}
+
+func TestGenerateWithCABundleFromSecret(t *testing.T) {
+	caPEM := testCAPEM(t)
+	client := fake.NewSimpleClientset(caSecret(map[string][]byte{"ca.crt": caPEM}))
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithCABundleFromSecret(client, "system", "webhook-ca", "ca.crt"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if cfg, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			if string(cfg.Webhooks[0].ClientConfig.CABundle) != string(caPEM) {
+				t.Error("expected the CABundle to be populated from the secret")
+			}
+		}
+	}
+}
+
+func TestGenerateMultiVersionWithCABundleFromSecret(t *testing.T) {
+	caPEM := testCAPEM(t)
+	client := fake.NewSimpleClientset(caSecret(map[string][]byte{"ca.crt": caPEM}))
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithCABundleFromSecret(client, "system", "webhook-ca", "ca.crt"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	output, err := o.GenerateMultiVersion([]string{"v1beta1", "v1"})
+	if err != nil {
+		t.Fatalf("expected GenerateMultiVersion to succeed, got %v", err)
+	}
+	for _, obj := range output["v1beta1"] {
+		if cfg, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			if string(cfg.Webhooks[0].ClientConfig.CABundle) != string(caPEM) {
+				t.Error("expected the v1beta1 CABundle to be populated from the secret")
+			}
+		}
+	}
+	for _, obj := range output["v1"] {
+		if cfg, ok := obj.(*admissionregistrationv1.MutatingWebhookConfiguration); ok {
+			if string(cfg.Webhooks[0].ClientConfig.CABundle) != string(caPEM) {
+				t.Error("expected the v1 CABundle to be populated from the secret")
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// certManagerInjectCAAnnotationKey makes the cert-manager CA injector
+	// populate the caBundle of the webhook configuration from the
+	// referenced Certificate.
+	certManagerInjectCAAnnotationKey = "cert-manager.io/inject-ca-from"
+	// certManagerIssuerNameAnnotationKey and
+	// certManagerIssuerKindAnnotationKey record on the Service which issuer
+	// provisions the serving certificate.
+	certManagerIssuerNameAnnotationKey = "cert-manager.io/issuer-name"
+	certManagerIssuerKindAnnotationKey = "cert-manager.io/issuer-kind"
+)
+
+// WithCertManagerIssuer provisions the webhook certificate through
+// cert-manager instead of the kubebuilder cert provisioner: the generated
+// configurations carry the inject-ca-from annotation pointing at the
+// Certificate — named by the configured secret, in the service namespace —
+// and the generated Service is annotated with the issuer reference.
+func WithCertManagerIssuer(ref cmmeta.ObjectReference) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.certManagerIssuer = &ref
+	}
+}
+
+// certManagerInjectCAAnnotation returns the inject-ca-from annotation, or
+// nil if cert-manager provisioning is not configured or the Certificate
+// location cannot be derived. The Certificate is assumed to share the name
+// of the configured secret and live in the secret's namespace, falling back
+// to the service namespace.
+func (o *generatorOptions) certManagerInjectCAAnnotation() map[string]string {
+	if o.certManagerIssuer == nil || o.secret == nil {
+		return nil
+	}
+	namespace := o.secret.Namespace
+	if len(namespace) == 0 && o.service != nil {
+		namespace = o.service.Namespace
+	}
+	if len(namespace) == 0 {
+		return nil
+	}
+	return map[string]string{certManagerInjectCAAnnotationKey: namespace + "/" + o.secret.Name}
+}
+
+// annotateServicesForCertManager records the issuer reference on the
+// generated services, so operators can see which issuer provisions the
+// serving certificate.
+func (o *generatorOptions) annotateServicesForCertManager(objects []runtime.Object) {
+	if o.certManagerIssuer == nil {
+		return
+	}
+	kind := o.certManagerIssuer.Kind
+	if len(kind) == 0 {
+		kind = "Issuer"
+	}
+	for _, obj := range objects {
+		svc, ok := obj.(*corev1.Service)
+		if !ok {
+			continue
+		}
+		if svc.Annotations == nil {
+			svc.Annotations = map[string]string{}
+		}
+		svc.Annotations[certManagerIssuerNameAnnotationKey] = o.certManagerIssuer.Name
+		svc.Annotations[certManagerIssuerKindAnnotationKey] = kind
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func certManagerGenerator(ref cmmeta.ObjectReference) *generatorOptions {
+	return NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithSecret(apitypes.NamespacedName{Namespace: "system", Name: "webhook-cert"}),
+		WithCertManagerIssuer(ref),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+}
+
+func TestCertManagerInjectCAAnnotation(t *testing.T) {
+	o := certManagerGenerator(cmmeta.ObjectReference{Name: "selfsigned", Kind: "ClusterIssuer"})
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var cfg *admissionregistration.MutatingWebhookConfiguration
+	for _, obj := range objects {
+		if c, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			cfg = c
+		}
+	}
+	if cfg == nil {
+		t.Fatal("expected a MutatingWebhookConfiguration to be generated")
+	}
+	if got := cfg.Annotations[certManagerInjectCAAnnotationKey]; got != "system/webhook-cert" {
+		t.Errorf("expected the inject-ca-from annotation, got %q", got)
+	}
+	if _, found := cfg.Annotations[caSecretAnnotationKey]; found {
+		t.Error("expected the ca-secret annotation to be replaced")
+	}
+}
+
+func TestCertManagerServiceAnnotations(t *testing.T) {
+	o := certManagerGenerator(cmmeta.ObjectReference{Name: "selfsigned", Kind: "ClusterIssuer"})
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var svc *corev1.Service
+	for _, obj := range objects {
+		if s, ok := obj.(*corev1.Service); ok {
+			svc = s
+		}
+	}
+	if svc == nil {
+		t.Fatal("expected a Service to be generated")
+	}
+	if got := svc.Annotations[certManagerIssuerNameAnnotationKey]; got != "selfsigned" {
+		t.Errorf("expected the issuer-name annotation, got %q", got)
+	}
+	if got := svc.Annotations[certManagerIssuerKindAnnotationKey]; got != "ClusterIssuer" {
+		t.Errorf("expected the issuer-kind annotation, got %q", got)
+	}
+}
+
+func TestCertManagerIssuerKindDefaulting(t *testing.T) {
+	o := certManagerGenerator(cmmeta.ObjectReference{Name: "webhook-issuer"})
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if svc, ok := obj.(*corev1.Service); ok {
+			if got := svc.Annotations[certManagerIssuerKindAnnotationKey]; got != "Issuer" {
+				t.Errorf("expected the issuer kind to default to Issuer, got %q", got)
+			}
+		}
+	}
+}
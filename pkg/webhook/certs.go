@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// Defaults for reading files from certDir. Kubernetes swaps the secret mount
+// symlink atomically during cert rotation, so a read can briefly race the
+// swap; a few short retries ride out the window.
+const (
+	defaultCertReadRetries  = 3
+	defaultCertReadInterval = 100 * time.Millisecond
+)
+
+// WithCertReadRetry sets how often and at what interval reads from certDir
+// are retried on transient errors.
+func WithCertReadRetry(retries int, interval time.Duration) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.certReadRetries = retries
+		o.certReadInterval = interval
+	}
+}
+
+// readCertFile reads the named file from certDir, retrying transient errors
+// with the configured bounded backoff.
+func (o *generatorOptions) readCertFile(name string) ([]byte, error) {
+	path := filepath.Join(o.certDir, name)
+	var lastErr error
+	for attempt := 0; attempt <= o.certReadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(o.certReadInterval * time.Duration(attempt))
+		}
+		content, err := ioutil.ReadFile(path)
+		if err == nil {
+			return content, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("failed to read %s after %d attempts: %v", path, o.certReadRetries+1, lastErr)
+}
+
+// LoadCABundle reads the named CA certificate file from certDir and sets it
+// as the CA bundle of the generated client configs, retrying transient reads
+// that race a cert rotation.
+func (o *generatorOptions) LoadCABundle(filename string) error {
+	content, err := o.readCertFile(filename)
+	if err != nil {
+		return err
+	}
+	o.caBundle = content
+	return nil
+}
+
+// allHosts returns the URL host followed by the extra HA hosts.
+func (o *generatorOptions) allHosts() []string {
+	var hosts []string
+	if o.host != nil {
+		hosts = append(hosts, *o.host)
+	}
+	return append(hosts, o.extraHosts...)
+}
+
+// ValidateServingCertHosts reads the named serving certificate file from
+// certDir and checks that its SANs cover every configured host. A server
+// behind several VIPs is only reachable through all of them with a
+// certificate covering each, and the URL client config alone can't surface
+// that mismatch.
+func (o *generatorOptions) ValidateServingCertHosts(filename string) error {
+	content, err := o.readCertFile(filename)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return fmt.Errorf("%s is not a PEM-encoded certificate", filename)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	hosts := o.allHosts()
+	if len(hosts) == 0 {
+		return errors.New("no hosts configured to validate against")
+	}
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			return fmt.Errorf("serving certificate does not cover host %q: %v", host, err)
+		}
+	}
+	return nil
+}
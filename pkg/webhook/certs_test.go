@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadCABundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-dir")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	if err := ioutil.WriteFile(filepath.Join(dir, "ca-cert.pem"), []byte("the-ca-bundle"), 0600); err != nil {
+		t.Fatalf("expected WriteFile to succeed, got %v", err)
+	}
+
+	o := NewGenerator(WithCertDir(dir))
+	if err := o.LoadCABundle("ca-cert.pem"); err != nil {
+		t.Fatalf("expected LoadCABundle to succeed, got %v", err)
+	}
+	if string(o.caBundle) != "the-ca-bundle" {
+		t.Errorf("unexpected CA bundle: %q", o.caBundle)
+	}
+}
+
+func TestReadCertFileRetriesDuringRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-dir")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	o := NewGenerator(WithCertDir(dir), WithCertReadRetry(5, 10*time.Millisecond))
+	// Simulate a cert rotation: the file shows up while a read is retrying.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ioutil.WriteFile(filepath.Join(dir, "tls.crt"), []byte("rotated"), 0600) // nolint: errcheck
+	}()
+	content, err := o.readCertFile("tls.crt")
+	if err != nil {
+		t.Fatalf("expected the read to succeed after retrying, got %v", err)
+	}
+	if string(content) != "rotated" {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
+
+func TestReadCertFileGivesUpAfterRetries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-dir")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	o := NewGenerator(WithCertDir(dir), WithCertReadRetry(2, time.Millisecond))
+	if _, err := o.readCertFile("missing.pem"); err == nil {
+		t.Fatal("expected reading a missing file to fail")
+	} else if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("expected the error to report the attempt count, got %v", err)
+	}
+}
+
+// writeServingCert writes a self-signed serving certificate covering the
+// given DNS names into dir as tls.crt.
+func writeServingCert(t *testing.T, dir string, dnsNames ...string) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create the certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := ioutil.WriteFile(filepath.Join(dir, "tls.crt"), certPEM, 0600); err != nil {
+		t.Fatalf("failed to write the certificate: %v", err)
+	}
+}
+
+func TestWithHostsURLUsesFirstHost(t *testing.T) {
+	o := NewGenerator(
+		WithHosts("vip-a.example.com", "vip-b.example.com"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	cc, err := o.getClientConfig()
+	if err != nil {
+		t.Fatalf("expected getClientConfig to succeed, got %v", err)
+	}
+	if cc.URL == nil || !strings.Contains(*cc.URL, "vip-a.example.com") {
+		t.Errorf("expected the URL to carry the first host, got %v", cc.URL)
+	}
+}
+
+func TestValidateServingCertHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-dir")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	writeServingCert(t, dir, "vip-a.example.com", "vip-b.example.com")
+
+	o := NewGenerator(
+		WithCertDir(dir),
+		WithHosts("vip-a.example.com", "vip-b.example.com"),
+	)
+	if err := o.ValidateServingCertHosts("tls.crt"); err != nil {
+		t.Errorf("expected a cert covering both VIPs to pass, got %v", err)
+	}
+}
+
+func TestValidateServingCertHostsMissingSAN(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cert-dir")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+	writeServingCert(t, dir, "vip-a.example.com")
+
+	o := NewGenerator(
+		WithCertDir(dir),
+		WithHosts("vip-a.example.com", "vip-b.example.com"),
+	)
+	err = o.ValidateServingCertHosts("tls.crt")
+	if err == nil {
+		t.Fatal("expected a cert missing the second VIP to fail")
+	}
+	if !strings.Contains(err.Error(), `"vip-b.example.com"`) {
+		t.Errorf("expected the error to name the uncovered host, got %v", err)
+	}
+}
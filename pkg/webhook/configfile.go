@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+// configFile is the YAML schema of a generator options file.
+type configFile struct {
+	Port                        int32                    `json:"port,omitempty"`
+	CertDir                     string                   `json:"certDir,omitempty"`
+	MutatingWebhookConfigName   string                   `json:"mutatingWebhookConfigName,omitempty"`
+	ValidatingWebhookConfigName string                   `json:"validatingWebhookConfigName,omitempty"`
+	Host                        string                   `json:"host,omitempty"`
+	Service                     *Service                 `json:"service,omitempty"`
+	Secret                      *apitypes.NamespacedName `json:"secret,omitempty"`
+	TargetK8sVersion            string                   `json:"targetK8sVersion,omitempty"`
+	Webhooks                    []configFileWebhook      `json:"webhooks,omitempty"`
+}
+
+// configFileWebhook is the YAML schema of one webhook entry in a generator
+// options file.
+type configFileWebhook struct {
+	Name              string                                     `json:"name"`
+	Type              string                                     `json:"type"`
+	Path              string                                     `json:"path"`
+	FailurePolicy     *admissionregistration.FailurePolicyType   `json:"failurePolicy,omitempty"`
+	SideEffects       *admissionregistration.SideEffectClass     `json:"sideEffects,omitempty"`
+	Rules             []admissionregistration.RuleWithOperations `json:"rules,omitempty"`
+	Labels            map[string]string                          `json:"labels,omitempty"`
+	Order             int                                        `json:"order,omitempty"`
+	NamespaceSelector *metav1.LabelSelector                      `json:"namespaceSelector,omitempty"`
+	ObjectSelector    *metav1.LabelSelector                      `json:"objectSelector,omitempty"`
+}
+
+// configFileFields and configFileWebhookFields are the fields the config
+// file schema knows, for rejecting misspelled fields that plain
+// unmarshalling would silently drop.
+var (
+	configFileFields = fieldSet("port", "certDir", "mutatingWebhookConfigName",
+		"validatingWebhookConfigName", "host", "service", "secret",
+		"targetK8sVersion", "webhooks")
+	configFileWebhookFields = fieldSet("name", "type", "path", "failurePolicy",
+		"sideEffects", "rules", "labels", "order", "namespaceSelector",
+		"objectSelector")
+)
+
+func fieldSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// LoadFromFile reads a YAML generator options file and builds the
+// corresponding generator options, ready for Generate().
+func LoadFromFile(path string) (*generatorOptions, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %v: %v", path, err)
+	}
+	if err := checkConfigFileFields(content); err != nil {
+		return nil, fmt.Errorf("invalid config file %v: %v", path, err)
+	}
+	config := configFile{}
+	// The underlying yaml errors reference the offending line, so they are
+	// passed through verbatim.
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse config file %v: %v", path, err)
+	}
+
+	opts := []GeneratorOption{}
+	if config.Port != 0 {
+		opts = append(opts, WithPort(config.Port))
+	}
+	if len(config.CertDir) != 0 {
+		opts = append(opts, WithCertDir(config.CertDir))
+	}
+	if len(config.MutatingWebhookConfigName) != 0 {
+		opts = append(opts, WithMutatingWebhookConfigName(config.MutatingWebhookConfigName))
+	}
+	if len(config.ValidatingWebhookConfigName) != 0 {
+		opts = append(opts, WithValidatingWebhookConfigName(config.ValidatingWebhookConfigName))
+	}
+	if len(config.Host) != 0 {
+		opts = append(opts, WithHost(config.Host))
+	}
+	if config.Service != nil {
+		opts = append(opts, WithService(*config.Service))
+	}
+	if config.Secret != nil {
+		opts = append(opts, WithSecret(*config.Secret))
+	}
+	if len(config.TargetK8sVersion) != 0 {
+		opts = append(opts, WithTargetK8sVersion(config.TargetK8sVersion))
+	}
+	for i := range config.Webhooks {
+		wh, err := config.Webhooks[i].toAdmissionWebhook()
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook entry %d in config file %v: %v", i, path, err)
+		}
+		opts = append(opts, WithWebhook(wh))
+	}
+	return NewGenerator(opts...), nil
+}
+
+// checkConfigFileFields rejects fields the config file schema does not know,
+// which plain unmarshalling would silently drop — typically misspellings.
+// The reported error references the offending line.
+func checkConfigFileFields(content []byte) error {
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return err
+	}
+	for key := range raw {
+		if !configFileFields[key] {
+			return fmt.Errorf("line %d: unknown field %q", lineOf(content, key), key)
+		}
+	}
+	webhooks, _ := raw["webhooks"].([]interface{})
+	for i, entry := range webhooks {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range fields {
+			if !configFileWebhookFields[key] {
+				return fmt.Errorf("line %d: unknown field %q in webhook entry %d", lineOf(content, key), key, i)
+			}
+		}
+	}
+	return nil
+}
+
+// lineOf returns the 1-based line number of the first line introducing the
+// given mapping key, or 0 if it cannot be located.
+func lineOf(content []byte, key string) int {
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		trimmed = strings.TrimPrefix(trimmed, "- ")
+		if strings.HasPrefix(trimmed, key+":") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// toAdmissionWebhook converts a config file webhook entry to an
+// admissionWebhook for the registry.
+func (c *configFileWebhook) toAdmissionWebhook() (*admissionWebhook, error) {
+	whType, err := parseWebhookType(c.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &admissionWebhook{
+		Name:              c.Name,
+		Type:              whType,
+		Path:              c.Path,
+		FailurePolicy:     c.FailurePolicy,
+		SideEffects:       c.SideEffects,
+		Rules:             c.Rules,
+		Labels:            c.Labels,
+		Order:             c.Order,
+		NamespaceSelector: c.NamespaceSelector,
+		ObjectSelector:    c.ObjectSelector,
+	}, nil
+}
+
+// parseWebhookType parses the string form of a webhook type as it appears in
+// a config file.
+func parseWebhookType(s string) (webhookType, error) {
+	switch s {
+	case "mutating":
+		return webhookTypeMutating, nil
+	case "validating":
+		return webhookTypeValidating, nil
+	case "both":
+		return webhookTypeBoth, nil
+	}
+	return 0, fmt.Errorf("unknown webhook type %q, supported types are mutating, validating and both", s)
+}
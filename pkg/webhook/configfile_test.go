@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfigFile = `port: 8443
+mutatingWebhookConfigName: test-mutating-webhook-cfg
+service:
+  name: webhook-service
+  namespace: test-system
+  selectors:
+    app: webhook-server
+webhooks:
+- name: mutatepods.example.com
+  type: mutating
+  path: /mutate-pods
+  rules:
+  - apiGroups: [""]
+    apiVersions: ["v1"]
+    resources: ["pods"]
+    operations: ["CREATE"]
+`
+
+func writeTestConfigFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "configfile-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) }) // nolint: errcheck
+	configPath := filepath.Join(dir, "webhookgen.yaml")
+	if err := ioutil.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadFromFile(t *testing.T) {
+	opts, err := LoadFromFile(writeTestConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("expected the config file to load, got %v", err)
+	}
+	if opts.port != 8443 {
+		t.Errorf("expected port 8443, got %d", opts.port)
+	}
+	if opts.mutatingWebhookConfigName != "test-mutating-webhook-cfg" {
+		t.Errorf("expected config name test-mutating-webhook-cfg, got %q", opts.mutatingWebhookConfigName)
+	}
+	if opts.service == nil || opts.service.Name != "webhook-service" {
+		t.Errorf("expected service webhook-service, got %+v", opts.service)
+	}
+	wh, found := opts.registry["/mutate-pods"]
+	if !found {
+		t.Fatalf("expected a webhook registered under /mutate-pods, got %v", opts.registry)
+	}
+	if wh.Type != webhookTypeMutating {
+		t.Errorf("expected a mutating webhook, got %v", wh.Type)
+	}
+	if len(wh.Rules) != 1 || wh.Rules[0].Resources[0] != "pods" {
+		t.Errorf("unexpected rules: %+v", wh.Rules)
+	}
+	if _, err := opts.Generate(); err != nil {
+		t.Errorf("expected the loaded options to generate, got %v", err)
+	}
+}
+
+func TestLoadFromFileInvalidType(t *testing.T) {
+	content := `webhooks:
+- name: foo
+  type: nonsense
+  path: /foo
+`
+	if _, err := LoadFromFile(writeTestConfigFile(t, content)); err == nil {
+		t.Error("expected an unknown webhook type to fail")
+	}
+}
+
+func TestLoadFromFileSelectors(t *testing.T) {
+	opts, err := LoadFromFile(writeTestConfigFile(t, `webhooks:
+- name: mutatepods.example.com
+  type: mutating
+  path: /mutate-pods
+  namespaceSelector:
+    matchLabels:
+      env: prod
+  objectSelector:
+    matchLabels:
+      app: payments
+`))
+	if err != nil {
+		t.Fatalf("expected the config file to load, got %v", err)
+	}
+	wh := opts.registry["/mutate-pods"]
+	if wh.NamespaceSelector == nil || wh.NamespaceSelector.MatchLabels["env"] != "prod" {
+		t.Errorf("expected the namespaceSelector to be loaded, got %v", wh.NamespaceSelector)
+	}
+	if wh.ObjectSelector == nil || wh.ObjectSelector.MatchLabels["app"] != "payments" {
+		t.Errorf("expected the objectSelector to be loaded, got %v", wh.ObjectSelector)
+	}
+}
+
+func TestLoadFromFileUnknownField(t *testing.T) {
+	_, err := LoadFromFile(writeTestConfigFile(t, `port: 8443
+pott: 9443
+`))
+	if err == nil {
+		t.Fatal("expected an unknown top-level field to be rejected")
+	}
+	if !strings.Contains(err.Error(), `line 2: unknown field "pott"`) {
+		t.Errorf("expected a line-referenced error, got %v", err)
+	}
+
+	_, err = LoadFromFile(writeTestConfigFile(t, `webhooks:
+- name: mutatepods.example.com
+  type: mutating
+  path: /mutate-pods
+  failurPolicy: Fail
+`))
+	if err == nil {
+		t.Fatal("expected an unknown webhook field to be rejected")
+	}
+	if !strings.Contains(err.Error(), `line 5: unknown field "failurPolicy"`) {
+		t.Errorf("expected a line-referenced error, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// defaultHealthzPath is the path the health endpoint defaults to.
+const defaultHealthzPath = "/healthz"
+
+// defaultReadyzPath is the path the readiness endpoint defaults to.
+const defaultReadyzPath = "/readyz"
+
+// DeploymentOptions configures the webhook server Deployment that Generate
+// optionally emits next to the Service, so simple setups don't need to
+// hand-write it. The pod labels are taken from the service selectors, so
+// the generated Service selects the generated pods.
+type DeploymentOptions struct {
+	// Name of the Deployment. Defaults to the service name.
+	Name string
+	// Namespace of the Deployment. Defaults to the service namespace.
+	Namespace string
+	// Image is the webhook server image.
+	Image string
+	// ContainerName is the name of the server container.
+	// Defaults to "webhook-server".
+	ContainerName string
+	// Replicas is the desired replica count. Nil leaves the API default.
+	Replicas *int32
+	// StartupProbe, when set, emits a startupProbe against the health
+	// endpoint so slow-starting servers — e.g. ones loading large schemas —
+	// are not killed by the liveness probe before they finish initializing.
+	StartupProbe *StartupProbeOptions
+}
+
+// StartupProbeOptions tunes the startup probe of the webhook server
+// container. The kubelet runs no other probes until the startup probe
+// succeeds, so the server gets up to FailureThreshold*PeriodSeconds to
+// initialize before the liveness probe — which should stay tight to catch
+// steady-state hangs — takes over.
+type StartupProbeOptions struct {
+	// FailureThreshold is how many probe failures to tolerate before the
+	// container is restarted. Zero leaves the API default.
+	FailureThreshold int32
+	// PeriodSeconds is how often to probe. Zero leaves the API default.
+	PeriodSeconds int32
+}
+
+// HealthEndpoint describes the health endpoint of the webhook server. When
+// set together with DeploymentOptions, the generated Deployment carries a
+// livenessProbe against it.
+type HealthEndpoint struct {
+	// Path of the endpoint. Defaults to /healthz.
+	Path string
+	// Port is the port the endpoint is served on, as a number or a named
+	// port. Defaults to the webhook port.
+	Port string
+}
+
+// ReadinessEndpoint describes the readiness endpoint of the webhook server.
+// When set together with DeploymentOptions, the generated Deployment
+// carries a readinessProbe against it, so pods receive no traffic until
+// certificates are loaded and reconciliation is done.
+type ReadinessEndpoint struct {
+	// Path of the endpoint. Defaults to /readyz.
+	Path string
+	// Port is the port the endpoint is served on, as a number or a named
+	// port. Defaults to the webhook port.
+	Port string
+	// ReadyFn reports whether the server is ready. The registered handler
+	// answers 503 until it returns true. Nil means ready immediately.
+	ReadyFn func() bool
+	// InitialDelaySeconds and PeriodSeconds tune the probe timing. Zero
+	// leaves the API defaults.
+	InitialDelaySeconds int32
+	PeriodSeconds       int32
+}
+
+// WithDeployment enables generating a Deployment running the webhook server.
+// It requires a service, whose selectors become the pod labels.
+func WithDeployment(d DeploymentOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.deployment = &d
+	}
+}
+
+// WithHealthEndpoint declares the health endpoint of the webhook server, so
+// the generated Deployment probes it for liveness.
+func WithHealthEndpoint(h HealthEndpoint) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.health = &h
+	}
+}
+
+// WithReadinessEndpoint declares the readiness endpoint of the webhook
+// server, so the generated Deployment probes it for readiness.
+func WithReadinessEndpoint(r ReadinessEndpoint) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.readiness = &r
+	}
+}
+
+// RegisterReadyz registers the readiness handler on the mux under the
+// configured path (default /readyz). It answers 503 until ReadyFn returns
+// true, and 200 from then on.
+func (o *generatorOptions) RegisterReadyz(mux *http.ServeMux) {
+	path := defaultReadyzPath
+	var ready func() bool
+	if o.readiness != nil {
+		if len(o.readiness.Path) != 0 {
+			path = o.readiness.Path
+		}
+		ready = o.readiness.ReadyFn
+	}
+	mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+		if ready != nil && !ready() {
+			http.Error(rw, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok")) // nolint: errcheck
+	})
+}
+
+// RegisterHealthz registers the health handler on the mux under the
+// configured path (default /healthz), answering HTTP 200 for liveness
+// probes.
+func (o *generatorOptions) RegisterHealthz(mux *http.ServeMux) {
+	path := defaultHealthzPath
+	if o.health != nil && len(o.health.Path) != 0 {
+		path = o.health.Path
+	}
+	mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		rw.Write([]byte("ok")) // nolint: errcheck
+	})
+}
+
+// webhookDeployment returns the Deployment running the webhook server, or
+// nil when no deployment options or no service are configured.
+func (o *generatorOptions) webhookDeployment() *appsv1.Deployment {
+	if o.deployment == nil || o.service == nil {
+		return nil
+	}
+	name := o.deployment.Name
+	if len(name) == 0 {
+		name = o.service.Name
+	}
+	namespace := o.deployment.Namespace
+	if len(namespace) == 0 {
+		namespace = o.service.Namespace
+	}
+	containerName := o.deployment.ContainerName
+	if len(containerName) == 0 {
+		containerName = "webhook-server"
+	}
+	container := corev1.Container{
+		Name:  containerName,
+		Image: o.deployment.Image,
+		Ports: []corev1.ContainerPort{{ContainerPort: o.port}},
+	}
+	if o.health != nil {
+		container.LivenessProbe = o.healthProbe()
+	}
+	if o.readiness != nil {
+		container.ReadinessProbe = o.readinessProbe()
+	}
+	if o.deployment.StartupProbe != nil {
+		container.StartupProbe = o.startupProbe()
+	}
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: o.service.Selectors},
+	}
+	if o.spiffe != nil {
+		// The SPIRE injector keys on this annotation to hand the pod its
+		// workload identity; the agent socket is mounted for the server to
+		// read its certificate from.
+		template.Annotations = map[string]string{spiffeManagedIdentityAnnotationKey: "true"}
+		volume, mount := o.spiffeAgentSocketVolume()
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+		template.Spec.Volumes = append(template.Spec.Volumes, volume)
+	}
+	template.Spec.Containers = []corev1.Container{container}
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    o.service.Selectors,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: o.deployment.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: o.service.Selectors},
+			Template: template,
+		},
+	}
+}
+
+// readinessProbe builds the readiness probe against the readiness endpoint.
+func (o *generatorOptions) readinessProbe() *corev1.Probe {
+	path := o.readiness.Path
+	if len(path) == 0 {
+		path = defaultReadyzPath
+	}
+	port := intstr.FromInt(int(o.port))
+	if len(o.readiness.Port) != 0 {
+		port = intstr.Parse(o.readiness.Port)
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: port,
+			},
+		},
+		InitialDelaySeconds: o.readiness.InitialDelaySeconds,
+		PeriodSeconds:       o.readiness.PeriodSeconds,
+	}
+}
+
+// startupProbe builds the startup probe. It targets the same health endpoint
+// as the liveness probe, with the configured higher failure tolerance.
+func (o *generatorOptions) startupProbe() *corev1.Probe {
+	path := defaultHealthzPath
+	port := intstr.FromInt(int(o.port))
+	if o.health != nil {
+		if len(o.health.Path) != 0 {
+			path = o.health.Path
+		}
+		if len(o.health.Port) != 0 {
+			port = intstr.Parse(o.health.Port)
+		}
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: port,
+			},
+		},
+		FailureThreshold: o.deployment.StartupProbe.FailureThreshold,
+		PeriodSeconds:    o.deployment.StartupProbe.PeriodSeconds,
+	}
+}
+
+// healthProbe builds the liveness probe against the health endpoint.
+func (o *generatorOptions) healthProbe() *corev1.Probe {
+	path := o.health.Path
+	if len(path) == 0 {
+		path = defaultHealthzPath
+	}
+	port := intstr.FromInt(int(o.port))
+	if len(o.health.Port) != 0 {
+		port = intstr.Parse(o.health.Port)
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: port,
+			},
+		},
+	}
+}
+
+// VerifyDeploymentPort checks that the named container of the webhook server
+// deployment declares the configured webhook port. The generated Service
+// targets that port, so a container that doesn't expose it produces a
+// webhook with no reachable backend. Returns an error listing the declared
+// ports on a mismatch.
+func (o *generatorOptions) VerifyDeploymentPort(dep *appsv1.Deployment, containerName string) error {
+	for i := range dep.Spec.Template.Spec.Containers {
+		container := &dep.Spec.Template.Spec.Containers[i]
+		if container.Name != containerName {
+			continue
+		}
+		declared := make([]int32, 0, len(container.Ports))
+		for _, port := range container.Ports {
+			if port.ContainerPort == o.port {
+				return nil
+			}
+			declared = append(declared, port.ContainerPort)
+		}
+		return fmt.Errorf("container %q of deployment %q does not declare the webhook port %d, declared ports are %v", containerName, dep.Name, o.port, declared)
+	}
+	return fmt.Errorf("deployment %q has no container %q", dep.Name, containerName)
+}
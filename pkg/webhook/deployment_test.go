@@ -0,0 +1,261 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func serverDeployment(ports ...int32) *appsv1.Deployment {
+	containerPorts := make([]corev1.ContainerPort, len(ports))
+	for i, port := range ports {
+		containerPorts[i] = corev1.ContainerPort{ContainerPort: port}
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook-server"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "manager", Ports: containerPorts},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestVerifyDeploymentPort(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithPort(9443),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+
+	if err := o.VerifyDeploymentPort(serverDeployment(8080, 9443), "manager"); err != nil {
+		t.Errorf("expected a matching container port to verify, got %v", err)
+	}
+
+	err := o.VerifyDeploymentPort(serverDeployment(8080), "manager")
+	if err == nil {
+		t.Fatal("expected a missing webhook port to fail")
+	}
+	if !strings.Contains(err.Error(), "[8080]") {
+		t.Errorf("expected the error to list the declared ports, got %v", err)
+	}
+
+	if err := o.VerifyDeploymentPort(serverDeployment(9443), "sidecar"); err == nil {
+		t.Error("expected an unknown container name to fail")
+	}
+}
+
+func TestGenerateDeploymentWithLivenessProbe(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithDeployment(DeploymentOptions{Image: "example.com/webhook-server:v1"}),
+		WithHealthEndpoint(HealthEndpoint{}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var deployment *appsv1.Deployment
+	for _, obj := range objects {
+		if d, ok := obj.(*appsv1.Deployment); ok {
+			deployment = d
+		}
+	}
+	if deployment == nil {
+		t.Fatal("expected a Deployment to be generated")
+	}
+	if deployment.Name != "webhook-service" || deployment.Namespace != "system" {
+		t.Errorf("expected the deployment to default name/namespace from the service, got %s/%s", deployment.Namespace, deployment.Name)
+	}
+	if deployment.Spec.Selector.MatchLabels["app"] != "webhook-server" {
+		t.Error("expected the pod selector to match the service selectors")
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != "example.com/webhook-server:v1" {
+		t.Errorf("expected the configured image, got %q", container.Image)
+	}
+	probe := container.LivenessProbe
+	if probe == nil || probe.HTTPGet == nil {
+		t.Fatal("expected an httpGet liveness probe")
+	}
+	if probe.HTTPGet.Path != "/healthz" {
+		t.Errorf("expected the probe path to default to /healthz, got %q", probe.HTTPGet.Path)
+	}
+	if probe.HTTPGet.Port.IntValue() != 443 {
+		t.Errorf("expected the probe port to default to the webhook port, got %v", probe.HTTPGet.Port)
+	}
+
+	// the generated deployment passes its own port check
+	if err := o.VerifyDeploymentPort(deployment, "webhook-server"); err != nil {
+		t.Errorf("expected the generated deployment to declare the webhook port, got %v", err)
+	}
+}
+
+func TestNoDeploymentWithoutOptions(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*appsv1.Deployment); ok {
+			t.Fatal("expected no Deployment without deployment options")
+		}
+	}
+}
+
+func TestRegisterHealthz(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithHealthEndpoint(HealthEndpoint{Path: "/livez"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	mux := http.NewServeMux()
+	o.RegisterHealthz(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/livez", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected the health handler to answer 200, got %d", recorder.Code)
+	}
+}
+
+func TestGenerateDeploymentWithReadinessProbe(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithDeployment(DeploymentOptions{Image: "example.com/webhook-server:v1"}),
+		WithReadinessEndpoint(ReadinessEndpoint{
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       10,
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var deployment *appsv1.Deployment
+	for _, obj := range objects {
+		if d, ok := obj.(*appsv1.Deployment); ok {
+			deployment = d
+		}
+	}
+	if deployment == nil {
+		t.Fatal("expected a Deployment to be generated")
+	}
+	probe := deployment.Spec.Template.Spec.Containers[0].ReadinessProbe
+	if probe == nil || probe.HTTPGet == nil {
+		t.Fatal("expected an httpGet readiness probe")
+	}
+	if probe.HTTPGet.Path != "/readyz" {
+		t.Errorf("expected the probe path to default to /readyz, got %q", probe.HTTPGet.Path)
+	}
+	if probe.InitialDelaySeconds != 5 || probe.PeriodSeconds != 10 {
+		t.Errorf("expected the probe timing to be carried over, got delay %d period %d",
+			probe.InitialDelaySeconds, probe.PeriodSeconds)
+	}
+}
+
+func TestRegisterReadyz(t *testing.T) {
+	ready := false
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithReadinessEndpoint(ReadinessEndpoint{ReadyFn: func() bool { return ready }}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	mux := http.NewServeMux()
+	o.RegisterReadyz(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before the server is ready, got %d", recorder.Code)
+	}
+
+	ready = true
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected 200 once the server is ready, got %d", recorder.Code)
+	}
+}
+
+func TestGenerateDeploymentWithStartupProbe(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithDeployment(DeploymentOptions{
+			Image:        "example.com/webhook-server:v1",
+			StartupProbe: &StartupProbeOptions{FailureThreshold: 30, PeriodSeconds: 2},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var deployment *appsv1.Deployment
+	for _, obj := range objects {
+		if d, ok := obj.(*appsv1.Deployment); ok {
+			deployment = d
+		}
+	}
+	if deployment == nil {
+		t.Fatal("expected a Deployment to be generated")
+	}
+	probe := deployment.Spec.Template.Spec.Containers[0].StartupProbe
+	if probe == nil || probe.HTTPGet == nil {
+		t.Fatal("expected an httpGet startup probe")
+	}
+	if probe.HTTPGet.Path != "/healthz" {
+		t.Errorf("expected the startup probe to target the health endpoint, got %q", probe.HTTPGet.Path)
+	}
+	if probe.FailureThreshold != 30 || probe.PeriodSeconds != 2 {
+		t.Errorf("expected the configured tolerance, got threshold %d period %d",
+			probe.FailureThreshold, probe.PeriodSeconds)
+	}
+}
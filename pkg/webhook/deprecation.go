@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// deprecatedAnnotationKey marks a generated configuration that contains
+	// deprecated webhooks.
+	deprecatedAnnotationKey = "webhook.kubernetes.io/deprecated"
+	// deprecationMessageAnnotationKey carries the human-readable deprecation
+	// messages of the contained webhooks.
+	deprecationMessageAnnotationKey = "webhook.kubernetes.io/deprecation-message"
+)
+
+// configAnnotations returns the annotations of the generated configuration
+// holding the webhooks of the given type: the ca-secret annotation, the
+// opt-in paths annotation, plus the deprecation annotations when deprecated
+// webhooks are among them.
+func (o *generatorOptions) configAnnotations(target webhookType) map[string]string {
+	annotations := o.caSecretAnnotation()
+	if o.annotatePaths {
+		if paths := o.pathsAnnotationValue(target); len(paths) != 0 {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[pathsAnnotationKey] = paths
+		}
+	}
+	var messages []string
+	for _, wh := range o.registry {
+		if !wh.Type.matches(target) || wh.disabled() || !wh.Deprecated {
+			continue
+		}
+		message := fmt.Sprintf("%s is deprecated", wh.Name)
+		if len(wh.DeprecationMessage) != 0 {
+			message = fmt.Sprintf("%s: %s", wh.Name, wh.DeprecationMessage)
+		}
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	// Sort for deterministic output; the registry is a map.
+	sort.Strings(messages)
+	annotations[deprecatedAnnotationKey] = "true"
+	annotations[deprecationMessageAnnotationKey] = strings.Join(messages, "; ")
+	return annotations
+}
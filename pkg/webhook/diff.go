@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Diff compares the YAML serialization of two sets of generated objects and
+// returns a unified-style line diff. An empty string means the sets are
+// identical. The objects are sorted by kind and name before serializing, so
+// the diff is stable across map iteration order.
+func Diff(old, new []runtime.Object) (string, error) {
+	oldYAML, err := marshalSorted(old)
+	if err != nil {
+		return "", err
+	}
+	newYAML, err := marshalSorted(new)
+	if err != nil {
+		return "", err
+	}
+	return DiffText(oldYAML, newYAML), nil
+}
+
+// DiffText returns a unified-style line diff between two texts. Unchanged
+// lines are prefixed with a space, removed lines with '-', and added lines
+// with '+'. An empty string means the texts are identical.
+func DiffText(old, new string) string {
+	if old == new {
+		return ""
+	}
+	oldLines := strings.Split(old, "\n")
+	newLines := strings.Split(new, "\n")
+
+	// Longest common subsequence over the lines.
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			fmt.Fprintf(&b, " %s\n", oldLines[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			fmt.Fprintf(&b, "-%s\n", oldLines[i])
+			i++
+		default:
+			fmt.Fprintf(&b, "+%s\n", newLines[j])
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		fmt.Fprintf(&b, "-%s\n", oldLines[i])
+	}
+	for ; j < len(newLines); j++ {
+		fmt.Fprintf(&b, "+%s\n", newLines[j])
+	}
+	return b.String()
+}
+
+// marshalSorted serializes the objects as one multi-document YAML string,
+// sorted by kind and name.
+func marshalSorted(objects []runtime.Object) (string, error) {
+	sorted := append([]runtime.Object(nil), objects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return objectSortKey(sorted[i]) < objectSortKey(sorted[j])
+	})
+	var b strings.Builder
+	for i := range sorted {
+		content, err := yaml.Marshal(sorted[i])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString("---\n")
+		b.Write(content)
+	}
+	return b.String(), nil
+}
+
+// objectSortKey returns a stable sort key for the object.
+func objectSortKey(obj runtime.Object) string {
+	key := obj.GetObjectKind().GroupVersionKind().Kind
+	if accessor, ok := obj.(metav1.Object); ok {
+		key += "/" + accessor.GetName()
+	}
+	return key
+}
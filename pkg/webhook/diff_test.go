@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	o := NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	diff, err := Diff(objects, objects)
+	if err != nil {
+		t.Fatalf("expected Diff to succeed, got %v", err)
+	}
+	if len(diff) != 0 {
+		t.Errorf("expected no diff for identical objects, got:\n%s", diff)
+	}
+}
+
+func TestDiffChanged(t *testing.T) {
+	oldGen := NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	oldObjects, err := oldGen.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	newWh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	newWh.Rules[0].Resources = []string{"statefulsets"}
+	newGen := NewGenerator(WithWebhook(newWh))
+	newObjects, err := newGen.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	diff, err := Diff(oldObjects, newObjects)
+	if err != nil {
+		t.Fatalf("expected Diff to succeed, got %v", err)
+	}
+	if !strings.Contains(diff, "-    - deployments") || !strings.Contains(diff, "+    - statefulsets") {
+		t.Errorf("expected the resource change in the diff, got:\n%s", diff)
+	}
+}
+
+func TestDiffAddedObject(t *testing.T) {
+	o := NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	diff, err := Diff([]runtime.Object{}, objects)
+	if err != nil {
+		t.Fatalf("expected Diff to succeed, got %v", err)
+	}
+	if !strings.Contains(diff, "+kind: MutatingWebhookConfiguration") {
+		t.Errorf("expected the added object in the diff, got:\n%s", diff)
+	}
+}
+
+func TestDiffText(t *testing.T) {
+	diff := DiffText("a\nb\nc\n", "a\nc\nd\n")
+	expected := " a\n-b\n c\n+d\n \n"
+	if diff != expected {
+		t.Errorf("expected diff %q, got %q", expected, diff)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"os"
+	"strconv"
+)
+
+// Environment variables read by FromEnv.
+const (
+	envPort             = "WEBHOOK_PORT"
+	envCertDir          = "WEBHOOK_CERT_DIR"
+	envServiceName      = "WEBHOOK_SERVICE_NAME"
+	envServiceNamespace = "WEBHOOK_SERVICE_NAMESPACE"
+	envHost             = "WEBHOOK_HOST"
+)
+
+// FromEnv reads generator options from the environment variables
+// WEBHOOK_PORT, WEBHOOK_CERT_DIR, WEBHOOK_SERVICE_NAME,
+// WEBHOOK_SERVICE_NAMESPACE and WEBHOOK_HOST.
+// It only fills in fields that have not been set yet, so values from the
+// environment take priority over the defaults but never override explicitly
+// passed options. Unparsable values, e.g. a non-numeric WEBHOOK_PORT, are
+// ignored.
+func FromEnv() GeneratorOption {
+	return func(o *generatorOptions) {
+		if v, ok := os.LookupEnv(envPort); ok && o.port == 0 {
+			if port, err := strconv.ParseInt(v, 10, 32); err == nil {
+				o.port = int32(port)
+			}
+		}
+		if v, ok := os.LookupEnv(envCertDir); ok && len(o.certDir) == 0 {
+			o.certDir = v
+		}
+		name, hasName := os.LookupEnv(envServiceName)
+		namespace, hasNamespace := os.LookupEnv(envServiceNamespace)
+		if hasName || hasNamespace {
+			if o.service == nil {
+				o.service = &Service{}
+			}
+			if hasName && len(o.service.Name) == 0 {
+				o.service.Name = name
+			}
+			if hasNamespace && len(o.service.Namespace) == 0 {
+				o.service.Namespace = namespace
+			}
+		}
+		if v, ok := os.LookupEnv(envHost); ok && o.host == nil && o.service == nil {
+			o.host = &v
+		}
+	}
+}
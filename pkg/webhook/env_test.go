@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(envPort, "8443")
+	t.Setenv(envCertDir, "/tmp/cert")
+	t.Setenv(envServiceName, "env-service")
+	t.Setenv(envServiceNamespace, "env-system")
+
+	o := NewGenerator(FromEnv())
+	if o.port != 8443 {
+		t.Errorf("expected port 8443 from environment, got %d", o.port)
+	}
+	if o.certDir != "/tmp/cert" {
+		t.Errorf("expected cert dir /tmp/cert from environment, got %q", o.certDir)
+	}
+	if o.service == nil || o.service.Name != "env-service" || o.service.Namespace != "env-system" {
+		t.Errorf("expected service env-service in env-system from environment, got %+v", o.service)
+	}
+	if o.host != nil {
+		t.Errorf("expected host to stay unset when a service is configured, got %q", *o.host)
+	}
+}
+
+func TestFromEnvDoesNotOverrideExplicitOptions(t *testing.T) {
+	t.Setenv(envPort, "8443")
+	t.Setenv(envHost, "env-host")
+
+	o := NewGenerator(FromEnv(), WithPort(9443), WithHost("explicit-host"))
+	if o.port != 9443 {
+		t.Errorf("expected explicit port 9443 to win over the environment, got %d", o.port)
+	}
+	if o.host == nil || *o.host != "explicit-host" {
+		t.Errorf("expected explicit host to win over the environment, got %v", o.host)
+	}
+}
+
+func TestFromEnvIgnoresInvalidPort(t *testing.T) {
+	t.Setenv(envPort, "not-a-port")
+
+	o := NewGenerator(FromEnv())
+	if o.port != 443 {
+		t.Errorf("expected invalid port to be ignored and defaulted to 443, got %d", o.port)
+	}
+}
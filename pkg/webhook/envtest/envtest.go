@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package envtest provides integration test helpers that install generated
+// webhook configurations into a controller-runtime envtest API server.
+package envtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// SetupWebhookTestEnv starts an envtest API server, installs the given
+// generated webhook configurations into it, and starts a local webhook HTTP
+// server that the configurations are rewritten to point at. The local server
+// answers every admission request with an allow, so the API server side of
+// admission (configuration validation, request dispatch) is what gets
+// exercised. The returned cleanup function stops both servers and is intended
+// for defer in tests.
+func SetupWebhookTestEnv(webhookConfigs []runtime.Object) (*envtest.Environment, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to listen on a local port: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(allowAll)}
+	go server.Serve(listener) // nolint: errcheck
+
+	env := &envtest.Environment{}
+	cleanup := func() {
+		server.Close() // nolint: errcheck
+		env.Stop()     // nolint: errcheck
+	}
+
+	cfg, err := env.Start()
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("unable to start the test environment: %v", err)
+	}
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("unable to create a client: %v", err)
+	}
+
+	baseURL := fmt.Sprintf("https://%v", listener.Addr())
+	for i := range webhookConfigs {
+		obj := webhookConfigs[i].DeepCopyObject()
+		redirectToLocalServer(obj, baseURL)
+		if err := c.Create(context.TODO(), obj); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("unable to install %T: %v", obj, err)
+		}
+	}
+	return env, cleanup, nil
+}
+
+// redirectToLocalServer rewrites service-based client configs in webhook
+// configurations to URLs pointing at the local test server, preserving each
+// webhook's path.
+func redirectToLocalServer(obj runtime.Object, baseURL string) {
+	switch config := obj.(type) {
+	case *admissionregistration.MutatingWebhookConfiguration:
+		for i := range config.Webhooks {
+			redirectClientConfig(&config.Webhooks[i].ClientConfig, baseURL)
+		}
+	case *admissionregistration.ValidatingWebhookConfiguration:
+		for i := range config.Webhooks {
+			redirectClientConfig(&config.Webhooks[i].ClientConfig, baseURL)
+		}
+	}
+}
+
+func redirectClientConfig(cc *admissionregistration.WebhookClientConfig, baseURL string) {
+	path := "/"
+	if cc.Service != nil && cc.Service.Path != nil {
+		path = *cc.Service.Path
+	}
+	url := baseURL + path
+	cc.Service = nil
+	cc.CABundle = nil
+	cc.URL = &url
+}
+
+// allowAll answers every AdmissionReview with an allow, echoing the UID.
+func allowAll(w http.ResponseWriter, r *http.Request) {
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review.Response = &admissionv1beta1.AdmissionResponse{Allowed: true}
+	if review.Request != nil {
+		review.Response.UID = review.Request.UID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review) // nolint: errcheck
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"reflect"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// EqualIgnoringManaged reports whether two webhook configurations or
+// services are semantically equal, ignoring fields the API server manages:
+// server-populated metadata, server-assigned service fields and
+// server-defaulted webhook fields. A generated object that leaves e.g.
+// failurePolicy nil is equal to its cluster-stored counterpart where the
+// server filled in the default, so reconcile loops comparing the two don't
+// issue endless updates. Objects of different types are never equal; objects
+// of types this package does not generate are compared as-is.
+func EqualIgnoringManaged(a, b runtime.Object) bool {
+	return reflect.DeepEqual(normalizeManaged(a), normalizeManaged(b))
+}
+
+// normalizeManaged returns a copy of the object with server-managed and
+// server-defaulted fields brought to a canonical form.
+func normalizeManaged(obj runtime.Object) runtime.Object {
+	obj = obj.DeepCopyObject()
+	switch typed := obj.(type) {
+	case *admissionregistration.MutatingWebhookConfiguration:
+		normalizeObjectMeta(&typed.ObjectMeta)
+		for i := range typed.Webhooks {
+			normalizeWebhookEntry(&typed.Webhooks[i])
+		}
+	case *admissionregistration.ValidatingWebhookConfiguration:
+		normalizeObjectMeta(&typed.ObjectMeta)
+		for i := range typed.Webhooks {
+			normalizeWebhookEntry(&typed.Webhooks[i])
+		}
+	case *corev1.Service:
+		normalizeObjectMeta(&typed.ObjectMeta)
+		normalizeServiceSpec(&typed.Spec)
+	}
+	return obj
+}
+
+// normalizeWebhookEntry pins the fields the API server defaults on a webhook
+// configuration entry: an unset caBundle is equal to an empty one, and unset
+// failurePolicy, matchPolicy and sideEffects are equal to their v1beta1
+// defaults.
+func normalizeWebhookEntry(wh *admissionregistration.Webhook) {
+	if len(wh.ClientConfig.CABundle) == 0 {
+		wh.ClientConfig.CABundle = nil
+	}
+	if wh.FailurePolicy == nil {
+		failurePolicy := admissionregistration.Ignore
+		wh.FailurePolicy = &failurePolicy
+	}
+	if wh.MatchPolicy == nil {
+		matchPolicy := admissionregistration.Exact
+		wh.MatchPolicy = &matchPolicy
+	}
+	if wh.SideEffects == nil {
+		sideEffects := admissionregistration.SideEffectClassUnknown
+		wh.SideEffects = &sideEffects
+	}
+}
+
+// normalizeServiceSpec clears the server-assigned clusterIP and pins the
+// spec fields the API server defaults.
+func normalizeServiceSpec(spec *corev1.ServiceSpec) {
+	spec.ClusterIP = ""
+	if len(spec.Type) == 0 {
+		spec.Type = corev1.ServiceTypeClusterIP
+	}
+	if len(spec.SessionAffinity) == 0 {
+		spec.SessionAffinity = corev1.ServiceAffinityNone
+	}
+	for i := range spec.Ports {
+		port := &spec.Ports[i]
+		if len(port.Protocol) == 0 {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort.IntVal == 0 && len(port.TargetPort.StrVal) == 0 {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+	}
+}
+
+// normalizeObjectMeta clears the metadata fields the API server populates on
+// every stored object.
+func normalizeObjectMeta(meta *metav1.ObjectMeta) {
+	meta.UID = ""
+	meta.ResourceVersion = ""
+	meta.Generation = 0
+	meta.CreationTimestamp = metav1.Time{}
+	meta.SelfLink = ""
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestEqualIgnoringManaged(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	generated := configs[0].(*admissionregistration.MutatingWebhookConfiguration)
+
+	// The cluster-stored counterpart carries server-populated metadata and
+	// server-defaulted webhook fields.
+	stored := generated.DeepCopy()
+	stored.ResourceVersion = "42"
+	stored.UID = "2f7dd1c9"
+	failurePolicy := admissionregistration.Ignore
+	matchPolicy := admissionregistration.Exact
+	stored.Webhooks[0].FailurePolicy = &failurePolicy
+	stored.Webhooks[0].MatchPolicy = &matchPolicy
+	stored.Webhooks[0].ClientConfig.CABundle = []byte{}
+
+	if !EqualIgnoringManaged(generated, stored) {
+		t.Error("expected the generated and stored configurations to be equal")
+	}
+
+	changed := stored.DeepCopy()
+	failClosed := admissionregistration.Fail
+	changed.Webhooks[0].FailurePolicy = &failClosed
+	if EqualIgnoringManaged(generated, changed) {
+		t.Error("expected a changed failurePolicy to be unequal")
+	}
+}
+
+func TestEqualIgnoringManagedService(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var generated *corev1.Service
+	for _, obj := range objects {
+		if svc, ok := obj.(*corev1.Service); ok {
+			generated = svc
+		}
+	}
+	if generated == nil {
+		t.Fatal("expected a Service to be generated")
+	}
+
+	stored := generated.DeepCopy()
+	stored.ResourceVersion = "7"
+	stored.Spec.ClusterIP = "10.0.0.42"
+	stored.Spec.Type = corev1.ServiceTypeClusterIP
+	stored.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	for i := range stored.Spec.Ports {
+		stored.Spec.Ports[i].Protocol = corev1.ProtocolTCP
+	}
+
+	if !EqualIgnoringManaged(generated, stored) {
+		t.Error("expected the generated and stored services to be equal")
+	}
+
+	changed := stored.DeepCopy()
+	changed.Spec.Selector = map[string]string{"app": "other"}
+	if EqualIgnoringManaged(generated, changed) {
+		t.Error("expected a changed selector to be unequal")
+	}
+
+	if EqualIgnoringManaged(generated, &admissionregistration.MutatingWebhookConfiguration{}) {
+		t.Error("expected objects of different types to be unequal")
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+// ParseFailurePolicy parses a failure policy given as a string, e.g. from a
+// config file or an environment variable. Matching is case-insensitive, so
+// "fail" and "ignore" are accepted alongside the canonical "Fail" and
+// "Ignore".
+func ParseFailurePolicy(s string) (*admissionregistration.FailurePolicyType, error) {
+	var policy admissionregistration.FailurePolicyType
+	switch {
+	case strings.EqualFold(s, string(admissionregistration.Fail)):
+		policy = admissionregistration.Fail
+	case strings.EqualFold(s, string(admissionregistration.Ignore)):
+		policy = admissionregistration.Ignore
+	default:
+		return nil, fmt.Errorf("invalid failurePolicy %q, should be Ignore or Fail", s)
+	}
+	return &policy, nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+func TestParseFailurePolicy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected admissionregistration.FailurePolicyType
+	}{
+		{input: "Fail", expected: admissionregistration.Fail},
+		{input: "fail", expected: admissionregistration.Fail},
+		{input: "FAIL", expected: admissionregistration.Fail},
+		{input: "Ignore", expected: admissionregistration.Ignore},
+		{input: "ignore", expected: admissionregistration.Ignore},
+	}
+	for _, test := range tests {
+		policy, err := ParseFailurePolicy(test.input)
+		if err != nil {
+			t.Errorf("expected parsing %q to succeed, got %v", test.input, err)
+			continue
+		}
+		if *policy != test.expected {
+			t.Errorf("expected parsing %q to yield %q, got %q", test.input, test.expected, *policy)
+		}
+	}
+}
+
+func TestParseFailurePolicyInvalid(t *testing.T) {
+	for _, input := range []string{"", "Maybe", "Failure"} {
+		if _, err := ParseFailurePolicy(input); err == nil {
+			t.Errorf("expected parsing %q to fail", input)
+		}
+	}
+}
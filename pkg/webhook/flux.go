@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fluxKustomizationName is the name of the generated Flux Kustomization.
+const fluxKustomizationName = "webhook-manifests"
+
+// fluxDefaultInterval is the reconcile interval used when none is configured.
+const fluxDefaultInterval = 10 * time.Minute
+
+// FluxOptions configures the generation of a Flux v2 Kustomization that
+// delivers the generated webhook manifests from a GitRepository source.
+type FluxOptions struct {
+	// GitRepositoryRef is the name of the GitRepository source holding the
+	// generated manifests.
+	GitRepositoryRef string
+	// Path is the directory within the source holding the generated
+	// manifests, e.g. the WriteManifestDir target.
+	Path string
+	// Interval is the reconcile interval, e.g. 10m. Defaults to 10m.
+	Interval string
+}
+
+// WithFlux enables generating a Flux Kustomization for the webhook
+// manifests.
+func WithFlux(f FluxOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.flux = &f
+	}
+}
+
+// fluxKustomization creates the Flux Kustomization pointing at the generated
+// manifests, with pruning enabled and health checks on the webhook server
+// Deployment and Service. It returns nil if Flux generation is not
+// configured.
+func (o *generatorOptions) fluxKustomization() runtime.Object {
+	if o.flux == nil {
+		return nil
+	}
+	interval := fluxDefaultInterval
+	if len(o.flux.Interval) != 0 {
+		if parsed, err := time.ParseDuration(o.flux.Interval); err == nil {
+			interval = parsed
+		}
+	}
+	var healthChecks []fluxmeta.NamespacedObjectKindReference
+	if o.service != nil {
+		// The Deployment running the webhook server is conventionally named
+		// after the service fronting it.
+		healthChecks = []fluxmeta.NamespacedObjectKindReference{
+			{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       o.service.Name,
+				Namespace:  o.service.Namespace,
+			},
+			{
+				Kind:      "Service",
+				Name:      o.service.Name,
+				Namespace: o.service.Namespace,
+			},
+		}
+	}
+	return &kustomizev1.Kustomization{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: kustomizev1.GroupVersion.String(),
+			Kind:       kustomizev1.KustomizationKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fluxKustomizationName,
+		},
+		Spec: kustomizev1.KustomizationSpec{
+			Interval: metav1.Duration{Duration: interval},
+			Path:     o.flux.Path,
+			Prune:    true,
+			SourceRef: kustomizev1.CrossNamespaceSourceReference{
+				Kind: "GitRepository",
+				Name: o.flux.GitRepositoryRef,
+			},
+			HealthChecks: healthChecks,
+		},
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+)
+
+func TestGenerateFluxKustomization(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithFlux(FluxOptions{
+			GitRepositoryRef: "deploy-repo",
+			Path:             "./manifests/webhooks",
+			Interval:         "5m",
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	var kustomization *kustomizev1.Kustomization
+	for _, obj := range objects {
+		if typed, ok := obj.(*kustomizev1.Kustomization); ok {
+			kustomization = typed
+		}
+	}
+	if kustomization == nil {
+		t.Fatal("expected a Kustomization to be generated")
+	}
+	if kustomization.Spec.SourceRef.Kind != "GitRepository" || kustomization.Spec.SourceRef.Name != "deploy-repo" {
+		t.Errorf("unexpected sourceRef: %+v", kustomization.Spec.SourceRef)
+	}
+	if kustomization.Spec.Path != "./manifests/webhooks" {
+		t.Errorf("unexpected path: %q", kustomization.Spec.Path)
+	}
+	if !kustomization.Spec.Prune {
+		t.Error("expected pruning to be enabled")
+	}
+	if kustomization.Spec.Interval.Duration != 5*time.Minute {
+		t.Errorf("unexpected interval: %v", kustomization.Spec.Interval)
+	}
+	if len(kustomization.Spec.HealthChecks) != 2 {
+		t.Fatalf("expected health checks for the Deployment and the Service, got %+v", kustomization.Spec.HealthChecks)
+	}
+	kinds := map[string]bool{}
+	for _, check := range kustomization.Spec.HealthChecks {
+		kinds[check.Kind] = true
+		if check.Name != "webhook-service" || check.Namespace != "system" {
+			t.Errorf("unexpected health check target: %+v", check)
+		}
+	}
+	if !kinds["Deployment"] || !kinds["Service"] {
+		t.Errorf("expected Deployment and Service health checks, got %v", kinds)
+	}
+}
+
+func TestGenerateFluxKustomizationDefaultInterval(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithFlux(FluxOptions{GitRepositoryRef: "deploy-repo", Path: "./manifests"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if kustomization, ok := obj.(*kustomizev1.Kustomization); ok {
+			if kustomization.Spec.Interval.Duration != fluxDefaultInterval {
+				t.Errorf("expected the default interval, got %v", kustomization.Spec.Interval)
+			}
+			// no service, so no health checks to point at
+			if len(kustomization.Spec.HealthChecks) != 0 {
+				t.Errorf("expected no health checks without a service, got %+v", kustomization.Spec.HealthChecks)
+			}
+			return
+		}
+	}
+	t.Fatal("expected a Kustomization to be generated")
+}
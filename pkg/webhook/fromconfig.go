@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// FromWebhookConfigurations reconstructs generator options from existing
+// webhook configuration objects, so that manifests written by hand or by
+// older tooling can be re-generated by this package. It populates the
+// registry, service or host, port and config names from the provided
+// MutatingWebhookConfiguration, ValidatingWebhookConfiguration and Service
+// objects.
+func FromWebhookConfigurations(objs []runtime.Object) (*generatorOptions, error) {
+	o := &generatorOptions{
+		registry: map[string]*admissionWebhook{},
+	}
+	for _, obj := range objs {
+		switch cfg := obj.(type) {
+		case *admissionregistration.MutatingWebhookConfiguration:
+			o.mutatingWebhookConfigName = cfg.Name
+			for i := range cfg.Webhooks {
+				if err := o.ingestWebhook(&cfg.Webhooks[i], webhookTypeMutating); err != nil {
+					return nil, err
+				}
+			}
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			o.validatingWebhookConfigName = cfg.Name
+			for i := range cfg.Webhooks {
+				if err := o.ingestWebhook(&cfg.Webhooks[i], webhookTypeValidating); err != nil {
+					return nil, err
+				}
+			}
+		case *corev1.Service:
+			if o.service == nil {
+				o.service = &Service{}
+			}
+			o.service.Name = cfg.Name
+			o.service.Namespace = cfg.Namespace
+			o.service.Selectors = cfg.Spec.Selector
+			for _, port := range cfg.Spec.Ports {
+				if port.TargetPort.IntVal > 0 {
+					o.port = port.TargetPort.IntVal
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported object type %T, expected webhook configurations or a service", obj)
+		}
+	}
+	o.setDefaults()
+	return o, nil
+}
+
+// ingestWebhook adds a single webhook configuration entry to the registry
+// and fills in the options-level client config it implies.
+func (o *generatorOptions) ingestWebhook(wh *admissionregistration.Webhook, whType webhookType) error {
+	registered := &admissionWebhook{
+		Type:              whType,
+		Rules:             wh.Rules,
+		FailurePolicy:     wh.FailurePolicy,
+		NamespaceSelector: wh.NamespaceSelector,
+		SideEffects:       wh.SideEffects,
+	}
+	registered.Name, registered.Order = splitOrderPrefix(wh.Name)
+
+	var path string
+	cc := wh.ClientConfig
+	switch {
+	case cc.Service != nil:
+		if cc.Service.Path != nil {
+			path = *cc.Service.Path
+		}
+		if o.service == nil {
+			o.service = &Service{}
+		}
+		o.service.Name = cc.Service.Name
+		o.service.Namespace = cc.Service.Namespace
+	case cc.URL != nil:
+		u, err := url.Parse(*cc.URL)
+		if err != nil {
+			return fmt.Errorf("webhook %q has unparsable URL: %v", wh.Name, err)
+		}
+		path = u.Path
+		host, portString, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			host = u.Host
+		} else {
+			port, err := strconv.ParseInt(portString, 10, 32)
+			if err != nil {
+				return fmt.Errorf("webhook %q has unparsable port in URL %q: %v", wh.Name, *cc.URL, err)
+			}
+			o.port = int32(port)
+		}
+		if o.host == nil {
+			o.host = &host
+		}
+	default:
+		return fmt.Errorf("webhook %q has neither a service nor a URL in its clientConfig", wh.Name)
+	}
+
+	if len(path) == 0 {
+		return fmt.Errorf("webhook %q has no path in its clientConfig", wh.Name)
+	}
+	registered.Path = path
+	// Entries generated from a webhook of type both carry a type prefix on
+	// their name; strip it so the mutating and validating entries coalesce.
+	registered.Name = strings.TrimPrefix(registered.Name, whType.String()+".")
+	if existing, found := o.registry[path]; found {
+		// A webhook present in both configs was generated from a single
+		// logical webhook of type both.
+		if existing.Name == registered.Name && existing.Type != whType {
+			existing.Type = webhookTypeBoth
+			return nil
+		}
+		return fmt.Errorf("duplicate webhook path %q in the provided configurations", path)
+	}
+	o.registry[path] = registered
+	return nil
+}
+
+// splitOrderPrefix splits a zero-padded order prefix, e.g. "01-", off a
+// webhook name. It is the inverse of the name generation in setDefaults.
+func splitOrderPrefix(name string) (string, int) {
+	if len(name) > 3 && name[2] == '-' {
+		if order, err := strconv.Atoi(name[:2]); err == nil {
+			return name[3:], order
+		}
+	}
+	return name, 0
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+)
+
+func TestFromWebhookConfigurationsRoundTrip(t *testing.T) {
+	original := NewGenerator(
+		WithPort(8443),
+		WithMutatingWebhookConfigName("my-mutating-config"),
+		WithValidatingWebhookConfigName("my-validating-config"),
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "server"}}),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+	objects, err := original.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	parsed, err := FromWebhookConfigurations(objects)
+	if err != nil {
+		t.Fatalf("expected FromWebhookConfigurations to succeed, got %v", err)
+	}
+	if parsed.mutatingWebhookConfigName != "my-mutating-config" {
+		t.Errorf("expected mutating config name to round-trip, got %q", parsed.mutatingWebhookConfigName)
+	}
+	if parsed.validatingWebhookConfigName != "my-validating-config" {
+		t.Errorf("expected validating config name to round-trip, got %q", parsed.validatingWebhookConfigName)
+	}
+	if parsed.port != 8443 {
+		t.Errorf("expected port 8443 to round-trip, got %d", parsed.port)
+	}
+	if parsed.service == nil || parsed.service.Name != "webhook-service" || parsed.service.Namespace != "system" {
+		t.Errorf("expected the fronting service to round-trip, got %+v", parsed.service)
+	}
+	if len(parsed.registry) != 2 {
+		t.Fatalf("expected 2 registered webhooks, got %d", len(parsed.registry))
+	}
+	mutating := parsed.registry["/mutate-pods"]
+	if mutating == nil || mutating.Type != webhookTypeMutating {
+		t.Errorf("expected a mutating webhook under /mutate-pods, got %+v", mutating)
+	}
+
+	// the reconstructed options should generate cleanly again
+	if _, err := parsed.Generate(); err != nil {
+		t.Errorf("expected regeneration from parsed options to succeed, got %v", err)
+	}
+}
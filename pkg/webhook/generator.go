@@ -0,0 +1,1395 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// labelRoutingPath is the shared path every webhook is served under when
+	// label routing is enabled.
+	labelRoutingPath = "/webhook"
+	// labelRoutingHandlerKey is the object label the server dispatches on
+	// when label routing is enabled; its value is the webhook name.
+	labelRoutingHandlerKey = "webhook.routing/handler"
+	// namespaceNameLabel is the label every namespace automatically carries
+	// since Kubernetes 1.21, holding the namespace name.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+	// controlPlaneLabel is the label namespace-selector defaulting keys on to
+	// exempt control-plane namespaces from service-backed webhooks.
+	controlPlaneLabel = "control-plane"
+)
+
+// generatorOptions contains the options for generating admission webhook
+// configuration objects and the service fronting the webhook server.
+type generatorOptions struct {
+	// port is the port number that the webhook server serves.
+	// It will be defaulted to 443 if unspecified.
+	port int32
+
+	// certDir is the directory that contains the server key and certificate.
+	certDir string
+
+	// certReadRetries is the number of times a read from certDir is retried
+	// on a transient error before giving up.
+	certReadRetries int
+	// certReadInterval is the base interval between certDir read retries.
+	certReadInterval time.Duration
+
+	// mutatingWebhookConfigName is the name used for creating the
+	// MutatingWebhookConfiguration object.
+	mutatingWebhookConfigName string
+	// webhookConfigGroup is the API group the generated webhook
+	// configurations are emitted under. It defaults to the upstream
+	// admissionregistration.k8s.io group and only needs changing for
+	// distributions serving admission configuration under a vendor group.
+	webhookConfigGroup string
+	// validatingWebhookConfigName is the name used for creating the
+	// ValidatingWebhookConfiguration object.
+	validatingWebhookConfigName string
+
+	// secret is the location of the secret holding the certificate for the
+	// webhook server. When set, the generated webhook configurations carry
+	// the ca-secret annotation so a CA provisioner can find the secret and
+	// populate the CABundle.
+	secret *apitypes.NamespacedName
+
+	// service is the k8s service fronting the webhook server pod(s).
+	// This field is optional. But one and only one of service and host can be set.
+	service *Service
+	// host is the host name of .webhooks.clientConfig.url.
+	// This field is optional. But one and only one of service and host can be set.
+	// If neither service nor host is specified, host will be defaulted to "localhost".
+	host *string
+	// extraHosts are additional host names an HA webhook server answers on.
+	// The URL client config only carries a single host, so these never end
+	// up in the generated configuration; they participate in serving
+	// certificate SAN validation, so a cert that only covers the URL host
+	// doesn't pass for a server behind several VIPs.
+	extraHosts []string
+
+	// strict disables the localhost defaulting of host, so leaving both host
+	// and service unset is an error instead. The lenient default is fine for
+	// local dev but in production it almost always means a misconfiguration:
+	// the generated config points the API server at its own localhost.
+	strict bool
+
+	// targetK8sVersion is the Kubernetes minor version, e.g. "1.14", that
+	// the generated configuration must apply cleanly to.
+	// This field is optional. If unspecified, all fields are assumed to be
+	// supported.
+	targetK8sVersion string
+
+	// defaultReinvocationPolicy is applied to mutating webhooks that don't
+	// set a reinvocation policy of their own. It is ignored for validating
+	// webhooks, which the API has no reinvocation policy for.
+	defaultReinvocationPolicy *admissionregistration.ReinvocationPolicyType
+
+	// registry maps a path to the webhook registered under it.
+	registry map[string]*admissionWebhook
+
+	// metrics counts notable events during generation.
+	// It will be defaulted to a no-op recorder if unspecified.
+	metrics Recorder
+
+	// monitoring, when set, makes Generate emit a Prometheus operator
+	// ServiceMonitor scraping the webhook server metrics.
+	monitoring *MonitoringOptions
+
+	// caBundle is the PEM CA bundle set on the generated client configs.
+	// If unset, the CABundle is left empty for a cert provisioner to fill in.
+	caBundle []byte
+
+	// caBundleSecret, when set, makes Generate fetch the CA bundle from an
+	// existing secret if no bundle is configured otherwise.
+	caBundleSecret *caBundleSecretRef
+
+	// omitEmptyCABundle controls how the CABundle placeholder is serialized
+	// when no bundle is configured: false (the default) emits an explicit
+	// empty value (caBundle: ""), which the kubebuilder cert provisioner
+	// expects as the field to patch; true omits the field entirely, which
+	// some GitOps tools prefer since their serializers drop empty fields.
+	omitEmptyCABundle bool
+
+	// requireFailClosed makes Validate reject validating webhooks whose
+	// failure policy is unset or Ignore, so fail-open validation can't ship
+	// by accident. Mutating webhooks are exempt.
+	requireFailClosed bool
+
+	// suppressDeleteWarning silences the Warnings entry for webhooks that
+	// watch DELETE, for servers whose handlers are known to work on
+	// oldObject.
+	suppressDeleteWarning bool
+
+	// annotatePaths stamps a JSON name-to-path map annotation onto the
+	// generated configurations, so apiserver log entries can be mapped back
+	// to the serving handler. The entry names in the config are mangled
+	// (order prefixes, type prefixes), which makes that mapping hard to do
+	// by eye.
+	annotatePaths bool
+
+	// labelRouting routes all webhooks through the shared /webhook path and
+	// distinguishes them by an objectSelector on the routing label instead,
+	// for servers that dispatch on object labels rather than URL paths. The
+	// caller is responsible for injecting the routing label on objects
+	// before the webhook fires. Requires Kubernetes 1.15+ (objectSelector).
+	labelRouting bool
+
+	// tracing, when set, makes Generate emit an OpenTelemetry collector
+	// configuration for a tracing sidecar next to the webhook server.
+	tracing *TracingOptions
+
+	// allowedClientCIDRs restricts the webhook server to connections from
+	// the given CIDRs, enforced by the AllowClientCIDRs middleware.
+	allowedClientCIDRs []string
+
+	// hmacSecret is the shared secret clients must sign request bodies with,
+	// enforced by the VerifyHMAC middleware.
+	hmacSecret string
+
+	// excludeOwnNamespace injects a namespaceSelector excluding ownNamespace
+	// into every webhook entry, so the webhooks can't deadlock the server
+	// that backs them. It keys on the automatic namespace name label and
+	// therefore requires Kubernetes 1.21+.
+	excludeOwnNamespace bool
+	// ownNamespace is the namespace the webhook server runs in.
+	ownNamespace string
+
+	// networkPolicy, when set, makes Generate emit a NetworkPolicy allowing
+	// ingress to the webhook port in the service namespace.
+	networkPolicy *NetworkPolicyOptions
+
+	// deployment, when set, makes Generate emit a Deployment running the
+	// webhook server behind the service.
+	deployment *DeploymentOptions
+	// health, when set, is the health endpoint of the webhook server; the
+	// generated Deployment probes it for liveness.
+	health *HealthEndpoint
+	// readiness, when set, is the readiness endpoint of the webhook server;
+	// the generated Deployment probes it for readiness.
+	readiness *ReadinessEndpoint
+
+	// podSecurityLevel, when set, makes Generate emit the service namespace
+	// as a Namespace object labeled with that Pod Security Admission
+	// enforcement level.
+	podSecurityLevel string
+
+	// spiffe, when set, provisions the server certificate through
+	// SPIFFE/SPIRE instead of a Kubernetes secret: Generate emits a
+	// ClusterSPIFFEID and the secret-based CA annotations are dropped.
+	spiffe *SPIFFEOptions
+
+	// provisioner, when set, is run by Generate to provision the serving
+	// certificate into certDir and supply the CA bundle when none is
+	// configured.
+	provisioner CertProvisioner
+
+	// certManagerIssuer, when set, is the cert-manager issuer provisioning
+	// the serving certificate; the generated configurations carry the
+	// inject-ca-from annotation instead of the ca-secret annotation.
+	certManagerIssuer *cmmeta.ObjectReference
+
+	// rbac, when set, makes Generate emit the RBAC objects the webhook
+	// server needs: a Role/RoleBinding for its cert secret and a
+	// ClusterRole reading the matched resources.
+	rbac *RBACOptions
+
+	// alerting, when set, makes Generate emit a Prometheus operator
+	// PrometheusRule with alerts for the fail-closed webhooks.
+	alerting *AlertingOptions
+
+	// argocd, when set, makes Generate emit an ArgoCD Application deploying
+	// the generated manifests from git.
+	argocd *ArgoCDOptions
+
+	// flux, when set, makes Generate emit a Flux Kustomization delivering
+	// the generated manifests from a GitRepository source.
+	flux *FluxOptions
+
+	// versionStore, when set, is where Checkpoint and Rollback keep
+	// configuration versions.
+	versionStore ConfigVersionStore
+}
+
+// Service contains information for creating a service fronting the webhook server.
+type Service struct {
+	// Name of the service.
+	Name string
+	// Namespace of the service.
+	Namespace string
+	// Selectors is the selector of the service.
+	// This must select the pods that run the webhook server.
+	Selectors map[string]string
+	// TypeSelectors optionally gives each webhook type its own selector, for
+	// topologies where the mutating and validating servers run as separate
+	// deployments with different pod labels. Keys are "mutating" and
+	// "validating". When set, one Service per type referenced by the registry
+	// is generated, named <Name>-<type>, and Selectors is ignored.
+	TypeSelectors map[string]map[string]string
+	// CABundle is the PEM CA bundle used to verify the serving certificate
+	// of this service. If unset, the options-level CA bundle applies.
+	CABundle []byte
+	// TypeCABundles optionally gives each webhook type its own CA bundle,
+	// for split deployments with independent cert lifecycles. Keys are
+	// "mutating" and "validating". Entries take precedence over CABundle.
+	TypeCABundles map[string][]byte
+	// Type is the type of the generated Service, e.g. LoadBalancer for a
+	// webhook server that an out-of-cluster API server must reach.
+	// Defaults to ClusterIP if unset.
+	Type corev1.ServiceType
+}
+
+// GeneratorOption configures the generator options.
+type GeneratorOption func(*generatorOptions)
+
+// WithPort sets the port that the webhook server serves.
+func WithPort(port int32) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.port = port
+	}
+}
+
+// WithCertDir sets the directory that contains the server key and certificate.
+func WithCertDir(certDir string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.certDir = certDir
+	}
+}
+
+// WithMutatingWebhookConfigName sets the name used for creating the
+// MutatingWebhookConfiguration object.
+func WithMutatingWebhookConfigName(name string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.mutatingWebhookConfigName = name
+	}
+}
+
+// WithValidatingWebhookConfigName sets the name used for creating the
+// ValidatingWebhookConfiguration object.
+func WithValidatingWebhookConfigName(name string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.validatingWebhookConfigName = name
+	}
+}
+
+// WithService sets the service fronting the webhook server.
+func WithService(svc Service) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.service = &svc
+	}
+}
+
+// WithHost sets the host name used in .webhooks.clientConfig.url.
+func WithHost(host string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.host = &host
+	}
+}
+
+// WithHosts sets the host names of a webhook server running behind several
+// VIPs for HA. The URL client config only supports a single host, so the
+// first host is used in .webhooks.clientConfig.url; the remaining hosts are
+// recorded so ValidateServingCertHosts can check the serving certificate
+// covers all of them.
+func WithHosts(hosts ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		if len(hosts) == 0 {
+			return
+		}
+		o.host = &hosts[0]
+		o.extraHosts = hosts[1:]
+	}
+}
+
+// WithStrict disables the localhost defaulting of host: with neither host
+// nor service set, Validate returns an error instead. Use this for
+// production configs, where an accidental localhost webhook is much more
+// likely than an intentional one.
+func WithStrict() GeneratorOption {
+	return func(o *generatorOptions) {
+		o.strict = true
+	}
+}
+
+// WithSecret sets the location of the secret holding the certificate for
+// the webhook server.
+func WithSecret(secret apitypes.NamespacedName) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.secret = &secret
+	}
+}
+
+// WithCABundle sets the PEM CA bundle used in the generated client configs.
+// Services can override it with a bundle of their own.
+func WithCABundle(caBundle []byte) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.caBundle = caBundle
+	}
+}
+
+// WithOmitEmptyCABundle controls the CABundle placeholder: when omit is
+// true, the caBundle field is omitted from the generated client configs
+// instead of being emitted as an explicit empty value. Keep the default
+// (explicit empty value) when the kubebuilder cert provisioner populates
+// the bundle; omit it when a GitOps tool owns the field and its serializer
+// drops empty values, to avoid spurious diffs between the two renderings.
+func WithOmitEmptyCABundle(omit bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.omitEmptyCABundle = omit
+	}
+}
+
+// WithWebhookConfigGroup sets the API group the generated webhook
+// configurations are emitted under, for distributions serving admission
+// configuration under a vendor group. Defaults to admissionregistration.k8s.io.
+func WithWebhookConfigGroup(group string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.webhookConfigGroup = group
+	}
+}
+
+// WithRequireFailClosed makes validation reject validating webhooks that
+// fail open (failurePolicy unset or Ignore), so a CI security gate can block
+// non-compliant configurations.
+func WithRequireFailClosed(require bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.requireFailClosed = require
+	}
+}
+
+// WithAnnotatePaths stamps an annotation with a JSON map of webhook name to
+// serving path onto the generated configurations, for mapping apiserver log
+// entries back to handlers during incident triage.
+func WithAnnotatePaths() GeneratorOption {
+	return func(o *generatorOptions) {
+		o.annotatePaths = true
+	}
+}
+
+// WithSuppressDeleteWarning silences the warning for webhooks watching
+// DELETE. Use it once the handlers are known to validate oldObject instead
+// of assuming object is present.
+func WithSuppressDeleteWarning(suppress bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.suppressDeleteWarning = suppress
+	}
+}
+
+// WithExcludeOwnNamespace injects a namespaceSelector excluding the given
+// namespace — the one the webhook server runs in — into every webhook entry,
+// preventing the self-deadlock where admission of the server's own resources
+// depends on the server being up. This keys on the automatic namespace name
+// label and is more targeted than the common control-plane label heuristic.
+func WithExcludeOwnNamespace(ownNamespace string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.excludeOwnNamespace = true
+		o.ownNamespace = ownNamespace
+	}
+}
+
+// WithLabelRouting serves every webhook under the shared /webhook path and
+// selects objects by the webhook.routing/handler label instead, for servers
+// that route requests on object labels rather than URL paths.
+func WithLabelRouting(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.labelRouting = enabled
+	}
+}
+
+// WithDefaultReinvocationPolicy sets the reinvocation policy applied to the
+// mutating webhooks that don't set one of their own.
+func WithDefaultReinvocationPolicy(policy admissionregistration.ReinvocationPolicyType) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.defaultReinvocationPolicy = &policy
+	}
+}
+
+// WithWebhook registers the given webhook(s) in the generator registry.
+func WithWebhook(webhooks ...*admissionWebhook) GeneratorOption {
+	return func(o *generatorOptions) {
+		for i := range webhooks {
+			o.registry[webhooks[i].Path] = webhooks[i]
+		}
+	}
+}
+
+// NewGenerator creates generator options with the given options applied on
+// top of the defaults.
+func NewGenerator(opts ...GeneratorOption) *generatorOptions {
+	o := &generatorOptions{
+		registry: map[string]*admissionWebhook{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.setDefaults()
+	return o
+}
+
+// setDefaults does defaulting for the generator options.
+func (o *generatorOptions) setDefaults() {
+	if o.registry == nil {
+		o.registry = map[string]*admissionWebhook{}
+	}
+	if o.port <= 0 {
+		o.port = 443
+	}
+	if len(o.certDir) == 0 {
+		o.certDir = path.Join("k8s-webhook-server", "cert")
+	}
+	if o.certReadRetries <= 0 {
+		o.certReadRetries = defaultCertReadRetries
+	}
+	if o.certReadInterval <= 0 {
+		o.certReadInterval = defaultCertReadInterval
+	}
+	if len(o.mutatingWebhookConfigName) == 0 {
+		o.mutatingWebhookConfigName = "mutating-webhook-configuration"
+	}
+	if len(o.webhookConfigGroup) == 0 {
+		o.webhookConfigGroup = admissionregistration.GroupName
+	}
+	if len(o.validatingWebhookConfigName) == 0 {
+		o.validatingWebhookConfigName = "validating-webhook-configuration"
+	}
+	if o.host == nil && o.service == nil && !o.strict {
+		varString := "localhost"
+		o.host = &varString
+	}
+	if o.metrics == nil {
+		o.metrics = noopRecorder{}
+	}
+	// Mutating webhooks fire in alphabetical name order, so encode the
+	// declared order into the name as a zero-padded prefix.
+	for _, wh := range o.registry {
+		if wh.Type.matches(webhookTypeMutating) && len(wh.Name) != 0 {
+			wh.Name = fmt.Sprintf("%02d-%s", wh.Order, wh.Name)
+		}
+	}
+}
+
+// Validate validates the cross-field invariants of the generator options.
+// It is called at the top of Generate, so misconfigurations produce one
+// clear error instead of partial output.
+func (o *generatorOptions) Validate() error {
+	if o.host != nil && o.service != nil {
+		return errors.New("host and service can't be set at the same time")
+	}
+	if o.host == nil && o.service == nil {
+		return errors.New("one of host and service should be set")
+	}
+	if o.port <= 0 || o.port > 65535 {
+		return fmt.Errorf("port should be in range 1-65535, got %d", o.port)
+	}
+	if o.service != nil {
+		// An invalid name or namespace is only rejected by the API server at
+		// apply time with a cryptic error, so catch it here and say which
+		// field is wrong.
+		if msgs := validation.IsDNS1123Label(o.service.Name); len(msgs) != 0 {
+			return fmt.Errorf("service name %q should be a DNS-1123 label: %s", o.service.Name, strings.Join(msgs, ", "))
+		}
+		if msgs := validation.IsDNS1123Label(o.service.Namespace); len(msgs) != 0 {
+			return fmt.Errorf("service namespace %q should be a DNS-1123 label: %s", o.service.Namespace, strings.Join(msgs, ", "))
+		}
+	}
+	if o.service != nil && len(o.service.Selectors) == 0 && len(o.service.TypeSelectors) == 0 {
+		return fmt.Errorf("service %q should have a non-empty selector, or it will select no endpoints and webhook requests will time out", o.service.Name)
+	}
+	if o.service != nil && len(o.service.Type) != 0 {
+		switch o.service.Type {
+		case corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+		default:
+			return fmt.Errorf("service %q has invalid type %q, should be ClusterIP, NodePort or LoadBalancer", o.service.Name, o.service.Type)
+		}
+	}
+	if len(o.mutatingWebhookConfigName) == 0 {
+		return errors.New("mutating webhook configuration name should not be empty")
+	}
+	if len(o.validatingWebhookConfigName) == 0 {
+		return errors.New("validating webhook configuration name should not be empty")
+	}
+	if msgs := validation.IsDNS1123Subdomain(o.webhookConfigGroup); len(msgs) != 0 {
+		return fmt.Errorf("webhook configuration group %q should be a DNS subdomain: %s", o.webhookConfigGroup, strings.Join(msgs, ", "))
+	}
+	if len(o.registry) == 0 {
+		return errors.New("no webhook is registered")
+	}
+	if len(o.targetK8sVersion) != 0 {
+		if _, err := parseK8sVersion(o.targetK8sVersion); err != nil {
+			return err
+		}
+	}
+	if o.defaultReinvocationPolicy != nil {
+		if err := validateReinvocationPolicy(*o.defaultReinvocationPolicy); err != nil {
+			return err
+		}
+	}
+	if err := o.validateOrdering(); err != nil {
+		return err
+	}
+	if o.requireFailClosed {
+		if err := o.validateFailClosed(); err != nil {
+			return err
+		}
+	}
+	if o.excludeOwnNamespace && len(o.ownNamespace) == 0 {
+		return errors.New("excluding the webhook's own namespace requires a non-empty namespace")
+	}
+	if len(o.podSecurityLevel) != 0 {
+		if err := validatePodSecurityLevel(o.podSecurityLevel); err != nil {
+			return err
+		}
+	}
+	if o.spiffe != nil && len(o.spiffe.TrustDomain) == 0 {
+		return errors.New("SPIFFE provisioning requires a non-empty trust domain")
+	}
+	if o.rbac != nil && len(o.rbac.ServiceAccount) == 0 {
+		return errors.New("RBAC generation requires a non-empty service account")
+	}
+	return nil
+}
+
+// validateFailClosed rejects validating webhooks that fail open, i.e. whose
+// failure policy is unset (the API default is Ignore) or explicitly Ignore.
+// Mutating webhooks are exempt: a skipped default is recoverable, a skipped
+// validation is not.
+func (o *generatorOptions) validateFailClosed() error {
+	for path, wh := range o.registry {
+		if !wh.Type.matches(webhookTypeValidating) || wh.disabled() {
+			continue
+		}
+		if wh.FailurePolicy == nil || *wh.FailurePolicy == admissionregistration.Ignore {
+			return fmt.Errorf("webhook %q (path %s) is fail-open: validating webhooks must set failurePolicy: Fail when fail-closed is required", wh.Name, path)
+		}
+	}
+	return nil
+}
+
+// ValidateWebhooks validates every registered webhook and the cross-field
+// invariants of the generator options, returning one error per invalid
+// webhook instead of stopping at the first. It also runs the configuration
+// generation itself, so problems that only surface there (e.g. client config
+// conflicts) are reported without producing output.
+func (o *generatorOptions) ValidateWebhooks() []error {
+	var errs []error
+	for path, wh := range o.registry {
+		if err := wh.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %q (path %s): %v", wh.Name, path, err))
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	if err := o.Validate(); err != nil {
+		return []error{err}
+	}
+	if _, err := o.whConfigs(); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// WebhookNames returns a path-to-name map of the registered webhooks after
+// defaulting and validation, without generating any configuration. The names
+// include the order prefix applied to mutating webhooks during defaulting,
+// so callers (e.g. CI) can assert that the emitted names are stable across
+// refactors; a changed name would orphan the old config in the cluster.
+// For webhooks of type both and webhooks with per-operation failure
+// policies, the emitted entry names are derived from the returned name by
+// prefixing the type or suffixing the policy.
+func (o *generatorOptions) WebhookNames() (map[string]string, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(o.registry))
+	for path, wh := range o.registry {
+		names[path] = wh.Name
+	}
+	return names, nil
+}
+
+// validateOrdering rejects mutating webhooks that declare the same order
+// while matching the same resource and operation, since their relative
+// firing order would be ambiguous.
+func (o *generatorOptions) validateOrdering() error {
+	byOrder := map[int][]*admissionWebhook{}
+	for _, wh := range o.registry {
+		if !wh.Type.matches(webhookTypeMutating) || wh.disabled() {
+			continue
+		}
+		for _, other := range byOrder[wh.Order] {
+			if wh.overlapsWith(other) {
+				return fmt.Errorf("webhooks %q and %q overlap and share order %d, their firing order would be ambiguous",
+					wh.Name, other.Name, wh.Order)
+			}
+		}
+		byOrder[wh.Order] = append(byOrder[wh.Order], wh)
+	}
+	return nil
+}
+
+// Generate creates the MutatingWebhookConfiguration and(or)
+// ValidatingWebhookConfiguration objects based on the registered webhooks,
+// and the Service object fronting the webhook server if any.
+//
+// The objects are returned in dependency order: the supporting objects
+// (namespace, secret-consuming RBAC, Service, Deployment, ...) come first
+// and the webhook configurations last, so applying the slice front to back
+// never registers a webhook before its backend exists.
+func (o *generatorOptions) Generate() ([]runtime.Object, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if err := o.sourceCABundle(); err != nil {
+		return nil, err
+	}
+	objects, err := o.whConfigs()
+	if err != nil {
+		return nil, err
+	}
+	services, err := o.supportingObjects()
+	if err != nil {
+		return nil, err
+	}
+	return append(services, objects...), nil
+}
+
+// sourceCABundle fills in the CA bundle from the configured secret or cert
+// provisioner when none is set, so every generation path hands out client
+// configs that trust the serving certificate.
+func (o *generatorOptions) sourceCABundle() error {
+	// An existing secret supplies the CA bundle when none is configured, so
+	// provisioning pipelines that store the CA in-cluster don't need to copy
+	// the PEM into the options by hand.
+	if o.caBundleSecret != nil && len(o.caBundle) == 0 {
+		ref := o.caBundleSecret
+		bundle, err := CABundleFromSecret(context.Background(), ref.client, ref.namespace, ref.name, ref.key)
+		if err != nil {
+			return fmt.Errorf("failed to read the CA bundle: %v", err)
+		}
+		o.caBundle = bundle
+	}
+	// A provisioner supplies the CA bundle when none is configured, so the
+	// generated client configs trust the freshly provisioned certificate.
+	if o.provisioner != nil && len(o.caBundle) == 0 {
+		bundle, err := o.provisioner.Provision(context.Background(), o.certDir)
+		if err != nil {
+			return fmt.Errorf("failed to provision the serving certificate: %v", err)
+		}
+		o.caBundle = bundle
+	}
+	return nil
+}
+
+// supportingObjects generates the objects emitted next to the webhook
+// configurations: the fronting Service(s) and whatever the optional
+// monitoring, tracing, alerting, GitOps and network policy features add.
+func (o *generatorOptions) supportingObjects() ([]runtime.Object, error) {
+	services, err := o.getServices()
+	if err != nil {
+		return nil, err
+	}
+	o.annotateServicesForCertManager(services)
+	if o.monitoring != nil {
+		o.addMetricsPorts(services)
+		services = append(services, o.serviceMonitors(services)...)
+	}
+	if cm := o.tracingConfigMap(); cm != nil {
+		services = append(services, cm)
+	}
+	if rule := o.prometheusRule(); rule != nil {
+		services = append(services, rule)
+	}
+	if app := o.argoCDApplication(); app != nil {
+		services = append(services, app)
+	}
+	if kustomization := o.fluxKustomization(); kustomization != nil {
+		services = append(services, kustomization)
+	}
+	if policy := o.ingressNetworkPolicy(); policy != nil {
+		services = append(services, policy)
+	}
+	if deployment := o.webhookDeployment(); deployment != nil {
+		services = append(services, deployment)
+	}
+	if spiffeID := o.clusterSPIFFEID(); spiffeID != nil {
+		services = append(services, spiffeID)
+	}
+	services = append(services, o.rbacObjects()...)
+	// The namespace goes first, before the objects living in it.
+	if namespace := o.webhookNamespace(); namespace != nil {
+		services = append([]runtime.Object{namespace}, services...)
+	}
+	return services, nil
+}
+
+// GenerateMultiVersion is like Generate, but emits one object set per
+// requested admissionregistration version ("v1beta1" or "v1"), keyed by
+// version. One pipeline can then apply v1beta1 to old clusters and v1 to
+// new ones, with the two outputs generated in lockstep from the same
+// options. Every set carries its own copy of the supporting objects.
+func (o *generatorOptions) GenerateMultiVersion(versions []string) (map[string][]runtime.Object, error) {
+	if len(versions) == 0 {
+		return nil, errors.New("no versions requested")
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if err := o.sourceCABundle(); err != nil {
+		return nil, err
+	}
+	output := make(map[string][]runtime.Object, len(versions))
+	for _, version := range versions {
+		if _, found := output[version]; found {
+			return nil, fmt.Errorf("duplicate version %q requested", version)
+		}
+		var configs []runtime.Object
+		var err error
+		switch version {
+		case "v1beta1":
+			configs, err = o.whConfigs()
+		case "v1":
+			configs, err = o.whConfigsV1()
+		default:
+			return nil, fmt.Errorf("unsupported admissionregistration version %q, supported versions are v1beta1 and v1", version)
+		}
+		if err != nil {
+			return nil, err
+		}
+		services, err := o.supportingObjects()
+		if err != nil {
+			return nil, err
+		}
+		// Keep the same dependency order as Generate: supporting objects
+		// first, configurations last.
+		output[version] = append(services, configs...)
+	}
+	return output, nil
+}
+
+// GenerateList is like Generate, but wraps the generated objects into a
+// single v1.List document for tooling that only accepts a List kind.
+// Each item carries its own TypeMeta so consumers can decode it.
+func (o *generatorOptions) GenerateList() (*corev1.List, error) {
+	objects, err := o.Generate()
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.List{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "List",
+		},
+	}
+	for i := range objects {
+		list.Items = append(list.Items, runtime.RawExtension{Object: objects[i]})
+	}
+	return list, nil
+}
+
+// GenerateFiltered is like Generate, but only emits webhooks whose declared
+// labels match the given selector. This allows GitOps tooling to apply or
+// prune a labeled subset of the registered webhooks.
+func (o *generatorOptions) GenerateFiltered(selector labels.Selector) ([]runtime.Object, error) {
+	filtered := *o
+	filtered.registry = map[string]*admissionWebhook{}
+	for path, wh := range o.registry {
+		if selector.Matches(labels.Set(wh.Labels)) {
+			filtered.registry[path] = wh
+		}
+	}
+	return filtered.Generate()
+}
+
+// whConfigs creates a mutatingWebhookConfiguration and(or) a validatingWebhookConfiguration based on registry.
+// For the same type of webhook configuration, it generates a webhook entry per endpoint.
+func (o *generatorOptions) whConfigs() ([]runtime.Object, error) {
+	objs := []runtime.Object{}
+	mutatingWH, err := o.mutatingWHConfig()
+	if err != nil {
+		return nil, err
+	}
+	if mutatingWH != nil {
+		objs = append(objs, mutatingWH)
+	}
+	validatingWH, err := o.validatingWHConfig()
+	if err != nil {
+		return nil, err
+	}
+	if validatingWH != nil {
+		objs = append(objs, validatingWH)
+	}
+	return objs, nil
+}
+
+// mutatingWHConfig creates the MutatingWebhookConfiguration object. It
+// returns nil if no mutating webhook is registered.
+func (o *generatorOptions) mutatingWHConfig() (runtime.Object, error) {
+	type orderedWebhook struct {
+		order   int
+		webhook admissionregistration.Webhook
+	}
+	ordered := []orderedWebhook{}
+	for path, webhook := range o.registry {
+		if !webhook.Type.matches(webhookTypeMutating) || webhook.disabled() {
+			continue
+		}
+		for _, expanded := range webhook.expandFailurePolicies() {
+			wh, err := o.admissionWebhook(path, expanded)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q at path %q: %w", expanded.Name, path, err)
+			}
+			wh.Name = expanded.nameFor(webhookTypeMutating)
+			wh.ReinvocationPolicy = expanded.ReinvocationPolicy
+			if wh.ReinvocationPolicy == nil {
+				wh.ReinvocationPolicy = o.defaultReinvocationPolicy
+			}
+			if wh.ClientConfig.Service != nil {
+				wh.ClientConfig.Service.Name = o.serviceNameFor(webhookTypeMutating)
+			}
+			if bundle := o.caBundleFor(webhookTypeMutating); len(bundle) != 0 {
+				wh.ClientConfig.CABundle = bundle
+			}
+			o.metrics.IncGenerated(webhookTypeMutating.String())
+			ordered = append(ordered, orderedWebhook{order: webhook.Order, webhook: *wh})
+		}
+	}
+
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+	// The API server invokes mutating webhooks in the order they appear in
+	// the configuration, so sort by the explicit order of the registered
+	// webhooks, falling back to name, instead of map iteration order.
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].order != ordered[j].order {
+			return ordered[i].order < ordered[j].order
+		}
+		return ordered[i].webhook.Name < ordered[j].webhook.Name
+	})
+	mutatingWebhooks := make([]admissionregistration.Webhook, len(ordered))
+	for i := range ordered {
+		mutatingWebhooks[i] = ordered[i].webhook
+	}
+	return &admissionregistration.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fmt.Sprintf("%s/%s", o.webhookConfigGroup, "v1beta1"),
+			Kind:       "MutatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        o.mutatingWebhookConfigName,
+			Annotations: o.configAnnotations(webhookTypeMutating),
+		},
+		Webhooks: mutatingWebhooks,
+	}, nil
+}
+
+// caSecretAnnotationKey is the annotation carrying the location of the
+// secret that a CA provisioner reads the certificate from.
+const caSecretAnnotationKey = "admission.kubebuilder.io/ca-secret"
+
+// pathsAnnotationKey is the annotation carrying the JSON map of webhook
+// names to the paths they are served under.
+const pathsAnnotationKey = "webhook.kubebuilder.io/paths"
+
+// pathsAnnotationValue returns the JSON map of webhook name to serving path
+// for the registered webhooks of the given type, or "" if there are none.
+// The names are the post-defaulting names, i.e. including the order prefix
+// of mutating webhooks.
+func (o *generatorOptions) pathsAnnotationValue(target webhookType) string {
+	paths := map[string]string{}
+	for path, wh := range o.registry {
+		if !wh.Type.matches(target) || wh.disabled() {
+			continue
+		}
+		paths[wh.Name] = path
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	content, _ := json.Marshal(paths) // nolint: errcheck
+	return string(content)
+}
+
+// caSecretAnnotation returns the ca-secret annotation for the generated
+// webhook configurations, or nil if no secret is configured. The value is
+// <namespace>/<name> when the secret has a namespace, and the bare name
+// otherwise for backward compatibility with cluster-wide provisioners.
+// With SPIFFE provisioning, the certificate comes from the SPIRE agent, so
+// no annotation is emitted even when a secret is configured. With a
+// cert-manager issuer, the inject-ca-from annotation replaces it.
+func (o *generatorOptions) caSecretAnnotation() map[string]string {
+	if o.certManagerIssuer != nil {
+		return o.certManagerInjectCAAnnotation()
+	}
+	if o.secret == nil || o.spiffe != nil {
+		return nil
+	}
+	value := o.secret.Name
+	if len(o.secret.Namespace) != 0 {
+		value = o.secret.Namespace + "/" + o.secret.Name
+	}
+	return map[string]string{caSecretAnnotationKey: value}
+}
+
+// validatingWHConfig creates the ValidatingWebhookConfiguration object. It
+// returns nil if no validating webhook is registered.
+func (o *generatorOptions) validatingWHConfig() (runtime.Object, error) {
+	validatingWebhooks := []admissionregistration.Webhook{}
+	for path, webhook := range o.registry {
+		if !webhook.Type.matches(webhookTypeValidating) || webhook.disabled() {
+			continue
+		}
+		for _, expanded := range webhook.expandFailurePolicies() {
+			wh, err := o.admissionWebhook(path, expanded)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q at path %q: %w", expanded.Name, path, err)
+			}
+			wh.Name = expanded.nameFor(webhookTypeValidating)
+			if wh.ClientConfig.Service != nil {
+				wh.ClientConfig.Service.Name = o.serviceNameFor(webhookTypeValidating)
+			}
+			if bundle := o.caBundleFor(webhookTypeValidating); len(bundle) != 0 {
+				wh.ClientConfig.CABundle = bundle
+			}
+			o.metrics.IncGenerated(webhookTypeValidating.String())
+			validatingWebhooks = append(validatingWebhooks, *wh)
+		}
+	}
+
+	if len(validatingWebhooks) == 0 {
+		return nil, nil
+	}
+	return &admissionregistration.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fmt.Sprintf("%s/%s", o.webhookConfigGroup, "v1beta1"),
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        o.validatingWebhookConfigName,
+			Annotations: o.configAnnotations(webhookTypeValidating),
+		},
+		Webhooks: validatingWebhooks,
+	}, nil
+}
+
+// admissionWebhook converts the registered webhook to an
+// admissionregistration.Webhook entry using the client config computed from
+// the generator options.
+func (o *generatorOptions) admissionWebhook(path string, wh *admissionWebhook) (*admissionregistration.Webhook, error) {
+	if err := wh.Validate(); err != nil {
+		o.metrics.IncValidationError(path)
+		return nil, err
+	}
+	rules := wh.Rules
+	if wh.Scope != nil {
+		if !o.supportsK8sVersion(1, 14) {
+			return nil, fmt.Errorf("webhook %q sets scope, which is not supported in kubernetes %s (requires 1.14+)", wh.Name, o.targetK8sVersion)
+		}
+		rules = make([]admissionregistration.RuleWithOperations, len(wh.Rules))
+		for i := range wh.Rules {
+			wh.Rules[i].DeepCopyInto(&rules[i])
+			rules[i].Scope = wh.Scope
+		}
+	}
+	// MatchConditions are deliberately not mapped: the v1beta1 API this
+	// generator emits has no matchConditions field. They are only populated
+	// when generating configuration for the v1 API.
+	namespaceSelector, err := o.namespaceSelectorFor(wh)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &admissionregistration.Webhook{
+		Name:              wh.Name,
+		Rules:             rules,
+		FailurePolicy:     wh.FailurePolicy,
+		NamespaceSelector: namespaceSelector,
+		SideEffects:       wh.SideEffects,
+	}
+	servePath, objectSelector, err := o.routingForWebhook(path, wh)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ObjectSelector = objectSelector
+	if wh.URL != nil {
+		// This webhook points at an external fixed URL instead of the
+		// host/service configured on the generator options.
+		cc := &admissionregistration.WebhookClientConfig{
+			CABundle: o.placeholderCABundle(),
+		}
+		urlString := *wh.URL
+		cc.URL = &urlString
+		if err := setPath(cc, servePath); err != nil {
+			return nil, err
+		}
+		webhook.ClientConfig = *cc
+		return webhook, nil
+	}
+	cc, err := o.getClientConfigWithPath(servePath)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ClientConfig = *cc
+	return webhook, nil
+}
+
+// routingForWebhook returns the served path and the effective objectSelector
+// of a webhook entry, applying the label routing rewrite and the bypass
+// annotation exclusion. Both the v1beta1 and the v1 builders go through
+// here, so the two output paths cannot drift apart. The returned selector is
+// a copy; the registered webhook is left untouched.
+func (o *generatorOptions) routingForWebhook(path string, wh *admissionWebhook) (string, *metav1.LabelSelector, error) {
+	servePath := path
+	if wh.ServePath != nil {
+		// The externally served path differs from the internal routing path,
+		// e.g. because a proxy rewrites it.
+		servePath = *wh.ServePath
+	}
+	var selector *metav1.LabelSelector
+	if wh.ObjectSelector != nil {
+		if !o.supportsK8sVersion(1, 15) {
+			return "", nil, fmt.Errorf("webhook %q sets an objectSelector, which is not supported in kubernetes %s (requires 1.15+)", wh.Name, o.targetK8sVersion)
+		}
+		selector = wh.ObjectSelector.DeepCopy()
+	}
+	if o.labelRouting {
+		if !o.supportsK8sVersion(1, 15) {
+			return "", nil, fmt.Errorf("label routing needs objectSelector, which is not supported in kubernetes %s (requires 1.15+)", o.targetK8sVersion)
+		}
+		// All webhooks share one path; the handler is picked by the routing
+		// label instead.
+		servePath = labelRoutingPath
+		if selector == nil {
+			selector = &metav1.LabelSelector{}
+		}
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = map[string]string{}
+		}
+		selector.MatchLabels[labelRoutingHandlerKey] = wh.Name
+	}
+	if len(wh.BypassAnnotationKey) != 0 {
+		if !o.supportsK8sVersion(1, 15) {
+			return "", nil, fmt.Errorf("webhook %q sets a bypass annotation, which needs objectSelector, not supported in kubernetes %s (requires 1.15+)", wh.Name, o.targetK8sVersion)
+		}
+		if selector == nil {
+			selector = &metav1.LabelSelector{}
+		}
+		selector.MatchExpressions = append(selector.MatchExpressions,
+			metav1.LabelSelectorRequirement{
+				Key:      wh.BypassAnnotationKey,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{wh.BypassAnnotationValue},
+			})
+	}
+	return servePath, selector, nil
+}
+
+// namespaceSelectorFor returns the namespaceSelector for a webhook entry.
+// For service-backed webhooks, a control-plane DoesNotExist requirement is
+// merged in to exempt control-plane namespaces; with excludeOwnNamespace set,
+// a requirement excluding the server's own namespace by its name label
+// follows. Both are merged into a copy of any registered selector rather
+// than dropped when one is set, so a user-provided selector cannot re-expose
+// the startup deadlock; the registered webhook is left untouched.
+func (o *generatorOptions) namespaceSelectorFor(wh *admissionWebhook) (*metav1.LabelSelector, error) {
+	selector := wh.NamespaceSelector
+	if o.service != nil {
+		selector = mergeSelectorRequirement(selector, metav1.LabelSelectorRequirement{
+			Key:      controlPlaneLabel,
+			Operator: metav1.LabelSelectorOpDoesNotExist,
+		})
+	}
+	if !o.excludeOwnNamespace {
+		return selector, nil
+	}
+	if !o.supportsK8sVersion(1, 21) {
+		return nil, fmt.Errorf("excluding the webhook's own namespace needs the %s label, which is not supported in kubernetes %s (requires 1.21+)", namespaceNameLabel, o.targetK8sVersion)
+	}
+	return mergeSelectorRequirement(selector, metav1.LabelSelectorRequirement{
+		Key:      namespaceNameLabel,
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   []string{o.ownNamespace},
+	}), nil
+}
+
+// mergeSelectorRequirement appends the requirement to a copy of the selector,
+// leaving the input untouched. An equal requirement already present is not
+// duplicated.
+func mergeSelectorRequirement(selector *metav1.LabelSelector, req metav1.LabelSelectorRequirement) *metav1.LabelSelector {
+	merged := &metav1.LabelSelector{}
+	if selector != nil {
+		selector.DeepCopyInto(merged)
+	}
+	for i := range merged.MatchExpressions {
+		if reflect.DeepEqual(merged.MatchExpressions[i], req) {
+			return merged
+		}
+	}
+	merged.MatchExpressions = append(merged.MatchExpressions, req)
+	return merged
+}
+
+// placeholderCABundle returns the CABundle placeholder used when no bundle
+// is configured: an explicit empty slice serialized as caBundle: "", or nil
+// to omit the field when omitEmptyCABundle is set.
+func (o *generatorOptions) placeholderCABundle() []byte {
+	if o.omitEmptyCABundle {
+		return nil
+	}
+	return []byte{}
+}
+
+// getClientConfig constructs a WebhookClientConfig based on the generator options.
+func (o *generatorOptions) getClientConfig() (*admissionregistration.WebhookClientConfig, error) {
+	if o.host != nil && o.service != nil {
+		return nil, errors.New("URL and Service can't be set at the same time")
+	}
+	cc := &admissionregistration.WebhookClientConfig{
+		// The CABundle is left as a placeholder. It is the responsibility of
+		// the cert provisioner to populate it.
+		CABundle: o.placeholderCABundle(),
+	}
+	if o.host != nil {
+		u := url.URL{
+			Scheme: "https",
+			Host:   net.JoinHostPort(*o.host, strconv.Itoa(int(o.port))),
+		}
+		urlString := u.String()
+		cc.URL = &urlString
+	}
+	if o.service != nil {
+		cc.Service = &admissionregistration.ServiceReference{
+			Name:      o.service.Name,
+			Namespace: o.service.Namespace,
+			// Path will be set later
+		}
+	}
+	return cc, nil
+}
+
+// getClientConfigWithPath constructs a WebhookClientConfig based on the generator options.
+// It will use path to the set the path in WebhookClientConfig.
+func (o *generatorOptions) getClientConfigWithPath(path string) (*admissionregistration.WebhookClientConfig, error) {
+	cc, err := o.getClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cc, setPath(cc, path)
+}
+
+// getV1ClientConfig constructs an admissionregistration/v1 WebhookClientConfig
+// based on the generator options. Unlike the v1beta1 variant, the v1
+// ServiceReference has a Port field, so the configured port is set explicitly
+// instead of letting clients fall back to the 443 default.
+func (o *generatorOptions) getV1ClientConfig() (*admissionregistrationv1.WebhookClientConfig, error) {
+	if o.host != nil && o.service != nil {
+		return nil, errors.New("URL and Service can't be set at the same time")
+	}
+	cc := &admissionregistrationv1.WebhookClientConfig{
+		// The CABundle is left as a placeholder. It is the responsibility of
+		// the cert provisioner to populate it.
+		CABundle: o.placeholderCABundle(),
+	}
+	if o.host != nil {
+		u := url.URL{
+			Scheme: "https",
+			Host:   net.JoinHostPort(*o.host, strconv.Itoa(int(o.port))),
+		}
+		urlString := u.String()
+		cc.URL = &urlString
+	}
+	if o.service != nil {
+		port := o.port
+		cc.Service = &admissionregistrationv1.ServiceReference{
+			Name:      o.service.Name,
+			Namespace: o.service.Namespace,
+			Port:      &port,
+			// Path will be set later
+		}
+	}
+	return cc, nil
+}
+
+// getV1ClientConfigWithPath constructs a v1 WebhookClientConfig based on the
+// generator options. It will use path to the set the path in WebhookClientConfig.
+func (o *generatorOptions) getV1ClientConfigWithPath(path string) (*admissionregistrationv1.WebhookClientConfig, error) {
+	cc, err := o.getV1ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cc, setV1Path(cc, path)
+}
+
+// setV1Path sets the path in the v1 WebhookClientConfig.
+func setV1Path(cc *admissionregistrationv1.WebhookClientConfig, path string) error {
+	if cc.URL != nil {
+		u, err := url.Parse(*cc.URL)
+		if err != nil {
+			return err
+		}
+		u.Path = path
+		urlString := u.String()
+		cc.URL = &urlString
+	}
+	if cc.Service != nil {
+		cc.Service.Path = &path
+	}
+	return nil
+}
+
+// setPath sets the path in the WebhookClientConfig.
+func setPath(cc *admissionregistration.WebhookClientConfig, path string) error {
+	if cc.URL != nil {
+		u, err := url.Parse(*cc.URL)
+		if err != nil {
+			return err
+		}
+		u.Path = path
+		urlString := u.String()
+		cc.URL = &urlString
+	}
+	if cc.Service != nil {
+		cc.Service.Path = &path
+	}
+	return nil
+}
+
+// getServices creates the Service objects fronting the webhook server. With
+// per-type selectors configured, one Service per webhook type referenced by
+// the registry is created, named deterministically <base>-mutating and
+// <base>-validating; otherwise a single Service with the shared selector is
+// created.
+func (o *generatorOptions) getServices() ([]runtime.Object, error) {
+	if o.service == nil {
+		return nil, nil
+	}
+	if len(o.service.TypeSelectors) == 0 {
+		return []runtime.Object{o.service.asService(o.port)}, nil
+	}
+	var services []runtime.Object
+	for _, whType := range []webhookType{webhookTypeMutating, webhookTypeValidating} {
+		selectors, found := o.service.TypeSelectors[whType.String()]
+		if !found {
+			continue
+		}
+		if len(selectors) == 0 {
+			return nil, fmt.Errorf("service %q has an empty selector for %v webhooks", o.service.Name, whType)
+		}
+		if !o.registryReferences(whType) {
+			continue
+		}
+		svc := Service{
+			Name:      o.serviceNameFor(whType),
+			Namespace: o.service.Namespace,
+			Selectors: selectors,
+			Type:      o.service.Type,
+		}
+		services = append(services, svc.asService(o.port))
+	}
+	return services, nil
+}
+
+// registryReferences returns true if any enabled registered webhook emits an
+// entry in the configuration for the given type.
+func (o *generatorOptions) registryReferences(whType webhookType) bool {
+	for _, wh := range o.registry {
+		if wh.Type.matches(whType) && !wh.disabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// caBundleFor returns the CA bundle to set on the client configs of the
+// webhooks of the given type: the service's per-type bundle if set, then the
+// service's own bundle, then the options-level bundle.
+func (o *generatorOptions) caBundleFor(whType webhookType) []byte {
+	if o.service != nil {
+		if bundle, found := o.service.TypeCABundles[whType.String()]; found {
+			return bundle
+		}
+		if len(o.service.CABundle) != 0 {
+			return o.service.CABundle
+		}
+	}
+	return o.caBundle
+}
+
+// serviceNameFor returns the name of the Service that fronts the webhooks of
+// the given type.
+func (o *generatorOptions) serviceNameFor(whType webhookType) string {
+	if o.service == nil {
+		return ""
+	}
+	if _, found := o.service.TypeSelectors[whType.String()]; found {
+		return fmt.Sprintf("%s-%v", o.service.Name, whType)
+	}
+	return o.service.Name
+}
+
+// asService creates a corev1.Service object fronting the webhook server.
+// It returns nil if the generator is not configured with a service.
+func (s *Service) asService(port int32) runtime.Object {
+	if s == nil {
+		return nil
+	}
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Name,
+			Namespace: s.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			// An empty type means ClusterIP, so the default is left implicit.
+			Type:     s.Type,
+			Selector: s.Selectors,
+			Ports: []corev1.ServicePort{
+				{
+					// When using service, kube-apiserver will send admission request to port 443.
+					Port:       443,
+					TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: port},
+				},
+			},
+		},
+	}
+}
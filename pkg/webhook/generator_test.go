@@ -0,0 +1,1298 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func testMutatingWebhook(name, path string) *admissionWebhook {
+	return &admissionWebhook{
+		Name: name,
+		Type: webhookTypeMutating,
+		Path: path,
+		Rules: []admissionregistration.RuleWithOperations{
+			{
+				Operations: []admissionregistration.OperationType{admissionregistration.Create},
+				Rule: admissionregistration.Rule{
+					APIGroups:   []string{"apps"},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"deployments"},
+				},
+			},
+		},
+	}
+}
+
+func testValidatingWebhook(name, path string) *admissionWebhook {
+	wh := testMutatingWebhook(name, path)
+	wh.Type = webhookTypeValidating
+	return wh
+}
+
+// countingRecorder counts the recorder invocations for tests.
+type countingRecorder struct {
+	generated        map[string]int
+	validationErrors map[string]int
+}
+
+func newCountingRecorder() *countingRecorder {
+	return &countingRecorder{
+		generated:        map[string]int{},
+		validationErrors: map[string]int{},
+	}
+}
+
+func (r *countingRecorder) IncGenerated(webhookType string) {
+	r.generated[webhookType]++
+}
+
+func (r *countingRecorder) IncValidationError(path string) {
+	r.validationErrors[path]++
+}
+
+func TestGenerate(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	// one mutating config, one validating config and one service
+	if len(objects) != 3 {
+		t.Errorf("expected 3 objects, got %d", len(objects))
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *generatorOptions
+		valid   bool
+	}{
+		{
+			name:    "defaults with a registered webhook",
+			options: NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods"))),
+			valid:   true,
+		},
+		{
+			name:    "empty registry",
+			options: NewGenerator(),
+			valid:   false,
+		},
+		{
+			name: "both host and service set",
+			options: func() *generatorOptions {
+				o := NewGenerator(WithHost("localhost"), WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+				o.service = &Service{Name: "svc", Namespace: "system"}
+				return o
+			}(),
+			valid: false,
+		},
+		{
+			name: "port out of range",
+			options: func() *generatorOptions {
+				o := NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+				o.port = 65536
+				return o
+			}(),
+			valid: false,
+		},
+		{
+			name: "empty mutating config name",
+			options: func() *generatorOptions {
+				o := NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+				o.mutatingWebhookConfigName = ""
+				return o
+			}(),
+			valid: false,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.options.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%s: expected options to be valid, got %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected options to be invalid", test.name)
+		}
+	}
+}
+
+func TestGenerateBothType(t *testing.T) {
+	wh := testMutatingWebhook("defaultandvalidatepods.example.com", "/pods")
+	wh.Type = webhookTypeBoth
+	o := NewGenerator(WithWebhook(wh))
+
+	objects, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected whConfigs to succeed, got %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected both a mutating and a validating config, got %d objects", len(objects))
+	}
+	names := map[string]bool{}
+	for _, obj := range objects {
+		switch cfg := obj.(type) {
+		case *admissionregistration.MutatingWebhookConfiguration:
+			for _, w := range cfg.Webhooks {
+				names[w.Name] = true
+			}
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			for _, w := range cfg.Webhooks {
+				names[w.Name] = true
+			}
+		}
+	}
+	if len(names) != 2 {
+		t.Errorf("expected distinct names across the two configs, got %v", names)
+	}
+}
+
+func TestGenerateScope(t *testing.T) {
+	scope := admissionregistration.NamespacedScope
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.Scope = &scope
+
+	o := NewGenerator(WithWebhook(wh))
+	converted, err := o.admissionWebhook(wh.Path, wh)
+	if err != nil {
+		t.Fatalf("expected conversion to succeed, got %v", err)
+	}
+	for _, rule := range converted.Rules {
+		if rule.Scope == nil || *rule.Scope != scope {
+			t.Errorf("expected scope %q to be applied to all rules, got %v", scope, rule.Scope)
+		}
+	}
+	// the webhook's own rules should be left untouched
+	for _, rule := range wh.Rules {
+		if rule.Scope != nil {
+			t.Error("expected the registered webhook's rules to be unmodified")
+		}
+	}
+
+	// scope requires 1.14+
+	o = NewGenerator(WithTargetK8sVersion("1.13"), WithWebhook(wh))
+	if _, err := o.admissionWebhook(wh.Path, wh); err == nil {
+		t.Error("expected conversion to fail when targeting kubernetes 1.13")
+	}
+}
+
+func TestGenerateURLOverride(t *testing.T) {
+	externalURL := "https://policy-engine.example.com:8443"
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	wh.URL = &externalURL
+
+	o := NewGenerator(WithService(Service{Name: "svc", Namespace: "system"}), WithWebhook(wh))
+	converted, err := o.admissionWebhook(wh.Path, wh)
+	if err != nil {
+		t.Fatalf("expected conversion to succeed, got %v", err)
+	}
+	if converted.ClientConfig.Service != nil {
+		t.Error("expected no service in the clientConfig when URL is set")
+	}
+	if converted.ClientConfig.URL == nil || *converted.ClientConfig.URL != externalURL+"/validate-pods" {
+		t.Errorf("expected URL %q, got %v", externalURL+"/validate-pods", converted.ClientConfig.URL)
+	}
+
+	invalid := "http://not-https.example.com"
+	wh.URL = &invalid
+	if err := wh.Validate(); err == nil {
+		t.Error("expected validation to fail for a non-https URL")
+	}
+}
+
+func TestValidateMatchConditions(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	wh.MatchConditions = []admissionregistrationv1.MatchCondition{
+		{Name: "exclude-kubelet", Expression: "request.userInfo.username != 'kubelet'"},
+	}
+	if err := wh.Validate(); err != nil {
+		t.Errorf("expected valid match conditions to pass validation, got %v", err)
+	}
+
+	wh.MatchConditions = append(wh.MatchConditions, admissionregistrationv1.MatchCondition{
+		Name: "exclude-kubelet", Expression: "true",
+	})
+	if err := wh.Validate(); err == nil {
+		t.Error("expected duplicate match condition names to fail validation")
+	}
+
+	wh.MatchConditions = []admissionregistrationv1.MatchCondition{{Name: "empty", Expression: ""}}
+	if err := wh.Validate(); err == nil {
+		t.Error("expected an empty match condition expression to fail validation")
+	}
+
+	// v1beta1 output must not carry match conditions
+	wh.MatchConditions = []admissionregistrationv1.MatchCondition{{Name: "c", Expression: "true"}}
+	o := NewGenerator(WithWebhook(wh))
+	if _, err := o.admissionWebhook(wh.Path, wh); err != nil {
+		t.Errorf("expected v1beta1 conversion to succeed by skipping match conditions, got %v", err)
+	}
+}
+
+func TestGenerateFiltered(t *testing.T) {
+	canary := testMutatingWebhook("canary.example.com", "/canary")
+	canary.Labels = map[string]string{"rollout": "canary"}
+	stable := testMutatingWebhook("stable.example.com", "/stable")
+	stable.Labels = map[string]string{"rollout": "stable"}
+
+	o := NewGenerator(WithWebhook(canary, stable))
+	selector, err := labels.Parse("rollout=canary")
+	if err != nil {
+		t.Fatalf("failed to parse selector: %v", err)
+	}
+	objects, err := o.GenerateFiltered(selector)
+	if err != nil {
+		t.Fatalf("expected GenerateFiltered to succeed, got %v", err)
+	}
+	cfg, ok := objects[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if !ok {
+		t.Fatalf("expected a MutatingWebhookConfiguration, got %T", objects[0])
+	}
+	if len(cfg.Webhooks) != 1 || cfg.Webhooks[0].Name != "00-canary.example.com" {
+		t.Errorf("expected only the canary webhook to be emitted, got %+v", cfg.Webhooks)
+	}
+	// the full registry should be untouched
+	if len(o.registry) != 2 {
+		t.Errorf("expected the registry to keep both webhooks, got %d", len(o.registry))
+	}
+}
+
+func TestGenerateDisabledWebhook(t *testing.T) {
+	// a disabled webhook is skipped without error, even if it is invalid
+	disabled := testMutatingWebhook("", "/disabled")
+	WithEnabled(false)(disabled)
+	enabled := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+
+	o := NewGenerator(WithWebhook(disabled, enabled))
+	objects, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected whConfigs to skip the disabled webhook, got %v", err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("expected one configuration, got %d", len(objects))
+	}
+	cfg := objects[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if len(cfg.Webhooks) != 1 || cfg.Webhooks[0].Name != "00-mutatepods.example.com" {
+		t.Errorf("expected only the enabled webhook to be emitted, got %+v", cfg.Webhooks)
+	}
+}
+
+func TestGenerateList(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{Name: "svc", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	list, err := o.GenerateList()
+	if err != nil {
+		t.Fatalf("expected GenerateList to succeed, got %v", err)
+	}
+	if list.Kind != "List" || list.APIVersion != "v1" {
+		t.Errorf("expected TypeMeta v1/List, got %s/%s", list.APIVersion, list.Kind)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(list.Items))
+	}
+	for _, item := range list.Items {
+		if item.Object.GetObjectKind().GroupVersionKind().Kind == "" {
+			t.Errorf("expected each item to carry its TypeMeta, got %+v", item.Object)
+		}
+	}
+}
+
+func TestMutatingWebhookOrdering(t *testing.T) {
+	first := testMutatingWebhook("first.example.com", "/first")
+	second := testMutatingWebhook("second.example.com", "/second")
+	second.Order = 1
+
+	o := NewGenerator(WithWebhook(first, second))
+	if err := o.Validate(); err != nil {
+		t.Fatalf("expected distinct orders to be valid, got %v", err)
+	}
+	if first.Name != "00-first.example.com" || second.Name != "01-second.example.com" {
+		t.Errorf("expected zero-padded order prefixes, got %q and %q", first.Name, second.Name)
+	}
+
+	// overlapping webhooks with the same order are ambiguous
+	a := testMutatingWebhook("a.example.com", "/a")
+	b := testMutatingWebhook("b.example.com", "/b")
+	o = NewGenerator(WithWebhook(a, b))
+	if err := o.Validate(); err == nil {
+		t.Error("expected overlapping webhooks with the same order to fail validation")
+	}
+}
+
+func TestCASecretAnnotation(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	o := NewGenerator(
+		WithSecret(apitypes.NamespacedName{Namespace: "system", Name: "webhook-cert"}),
+		WithWebhook(wh),
+	)
+	objects, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected whConfigs to succeed, got %v", err)
+	}
+	cfg := objects[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if got := cfg.Annotations[caSecretAnnotationKey]; got != "system/webhook-cert" {
+		t.Errorf("expected ca-secret annotation system/webhook-cert, got %q", got)
+	}
+
+	// bare name output is kept when no namespace is set
+	o = NewGenerator(
+		WithSecret(apitypes.NamespacedName{Name: "webhook-cert"}),
+		WithWebhook(wh),
+	)
+	objects, err = o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected whConfigs to succeed, got %v", err)
+	}
+	cfg = objects[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if got := cfg.Annotations[caSecretAnnotationKey]; got != "webhook-cert" {
+		t.Errorf("expected ca-secret annotation webhook-cert, got %q", got)
+	}
+}
+
+func TestGenerateMetrics(t *testing.T) {
+	r := newCountingRecorder()
+	o := NewGenerator(
+		WithMetrics(r),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+
+	if _, err := o.Generate(); err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	if r.generated["mutating"] != 1 || r.generated["validating"] != 1 {
+		t.Errorf("expected one mutating and one validating webhook recorded, got %v", r.generated)
+	}
+
+	r = newCountingRecorder()
+	invalid := testMutatingWebhook("", "/invalid")
+	o = NewGenerator(WithMetrics(r), WithWebhook(invalid))
+	if _, err := o.Generate(); err == nil {
+		t.Fatal("expected Generate to fail for an invalid webhook")
+	}
+	if r.validationErrors["/invalid"] != 1 {
+		t.Errorf("expected one validation error recorded for /invalid, got %v", r.validationErrors)
+	}
+}
+
+func TestGetV1ClientConfigPort(t *testing.T) {
+	o := NewGenerator(
+		WithPort(8443),
+		WithService(Service{Name: "webhook-service", Namespace: "test-system"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	cc, err := o.getV1ClientConfigWithPath("/mutate-pods")
+	if err != nil {
+		t.Fatalf("expected the client config to be built, got %v", err)
+	}
+	if cc.Service == nil {
+		t.Fatal("expected a service reference in the client config")
+	}
+	if cc.Service.Port == nil || *cc.Service.Port != 8443 {
+		t.Errorf("expected service port 8443, got %v", cc.Service.Port)
+	}
+	if cc.Service.Path == nil || *cc.Service.Path != "/mutate-pods" {
+		t.Errorf("expected service path /mutate-pods, got %v", cc.Service.Path)
+	}
+
+	// The v1beta1 client config should leave the port unset.
+	bcc, err := o.getClientConfigWithPath("/mutate-pods")
+	if err != nil {
+		t.Fatalf("expected the v1beta1 client config to be built, got %v", err)
+	}
+	if bcc.Service == nil || bcc.Service.Port != nil {
+		t.Errorf("expected the v1beta1 service port to be unset, got %+v", bcc.Service)
+	}
+}
+
+func TestValidateWebhooks(t *testing.T) {
+	o := NewGenerator(WithWebhook(
+		testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+		testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+	))
+	if errs := o.ValidateWebhooks(); len(errs) != 0 {
+		t.Errorf("expected no validation errors, got %v", errs)
+	}
+
+	// Invalid webhooks are all reported, not just the first.
+	o = NewGenerator(WithWebhook(
+		testMutatingWebhook("", "/a"),
+		testValidatingWebhook("", "/b"),
+	))
+	if errs := o.ValidateWebhooks(); len(errs) != 2 {
+		t.Errorf("expected 2 validation errors, got %v", errs)
+	}
+}
+
+func TestGenerateTypeSelectorServices(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			TypeSelectors: map[string]map[string]string{
+				"mutating":   {"app": "mutating-server"},
+				"validating": {"app": "validating-server"},
+			},
+		}),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	services := map[string]*corev1.Service{}
+	for _, obj := range objects {
+		if svc, ok := obj.(*corev1.Service); ok {
+			services[svc.Name] = svc
+		}
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected 2 services, got %v", services)
+	}
+	if svc := services["webhook-service-mutating"]; svc == nil || svc.Spec.Selector["app"] != "mutating-server" {
+		t.Errorf("unexpected mutating service: %+v", svc)
+	}
+	if svc := services["webhook-service-validating"]; svc == nil || svc.Spec.Selector["app"] != "validating-server" {
+		t.Errorf("unexpected validating service: %+v", svc)
+	}
+
+	// The webhook entries point at the per-type services.
+	for _, obj := range objects {
+		switch cfg := obj.(type) {
+		case *admissionregistration.MutatingWebhookConfiguration:
+			if name := cfg.Webhooks[0].ClientConfig.Service.Name; name != "webhook-service-mutating" {
+				t.Errorf("expected the mutating entry to use webhook-service-mutating, got %q", name)
+			}
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			if name := cfg.Webhooks[0].ClientConfig.Service.Name; name != "webhook-service-validating" {
+				t.Errorf("expected the validating entry to use webhook-service-validating, got %q", name)
+			}
+		}
+	}
+}
+
+func TestGenerateTypeSelectorEmptySelector(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:          "webhook-service",
+			Namespace:     "test-system",
+			TypeSelectors: map[string]map[string]string{"mutating": {}},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if _, err := o.Generate(); err == nil {
+		t.Error("expected an empty per-type selector to fail")
+	}
+}
+
+func TestValidateEmptyServiceSelector(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "test-system"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an empty service selector to fail validation")
+	}
+}
+
+func TestWarningsControlPlaneSelector(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"control-plane": "webhook-server"},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if warnings := o.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning for the control-plane selector key, got %v", warnings)
+	}
+}
+
+func TestCABundlePerService(t *testing.T) {
+	o := NewGenerator(
+		WithCABundle([]byte("options-ca")),
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			TypeSelectors: map[string]map[string]string{
+				"mutating":   {"app": "mutating-server"},
+				"validating": {"app": "validating-server"},
+			},
+			TypeCABundles: map[string][]byte{
+				"mutating": []byte("mutating-ca"),
+			},
+		}),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+	objects, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected whConfigs to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		switch cfg := obj.(type) {
+		case *admissionregistration.MutatingWebhookConfiguration:
+			if got := string(cfg.Webhooks[0].ClientConfig.CABundle); got != "mutating-ca" {
+				t.Errorf("expected the mutating entry to use the per-type bundle, got %q", got)
+			}
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			// No validating bundle is configured, so the options-level one applies.
+			if got := string(cfg.Webhooks[0].ClientConfig.CABundle); got != "options-ca" {
+				t.Errorf("expected the validating entry to fall back to the options bundle, got %q", got)
+			}
+		}
+	}
+}
+
+func TestGenerateErrorCarriesNameAndPath(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	// An http URL fails webhook validation inside admissionWebhook.
+	badURL := "http://example.com"
+	wh.URL = &badURL
+
+	o := NewGenerator(WithHost("localhost"), WithWebhook(wh))
+	_, err := o.Generate()
+	if err == nil {
+		t.Fatal("expected Generate to fail for an http URL")
+	}
+	for _, want := range []string{wh.Name, wh.Path} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q to contain %q", err, want)
+		}
+	}
+}
+
+func TestDefaultReinvocationPolicy(t *testing.T) {
+	never := admissionregistration.NeverReinvocationPolicy
+	withOwn := testMutatingWebhook("withown.example.com", "/with-own")
+	withOwn.ReinvocationPolicy = &never
+	withoutOwn := testMutatingWebhook("withoutown.example.com", "/without-own")
+
+	o := NewGenerator(
+		WithDefaultReinvocationPolicy(admissionregistration.IfNeededReinvocationPolicy),
+		WithWebhook(withOwn, withoutOwn),
+	)
+	objects, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected whConfigs to succeed, got %v", err)
+	}
+	cfg, ok := objects[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if !ok {
+		t.Fatalf("expected a MutatingWebhookConfiguration, got %T", objects[0])
+	}
+	for _, wh := range cfg.Webhooks {
+		switch {
+		case strings.Contains(wh.Name, "withown"):
+			if wh.ReinvocationPolicy == nil || *wh.ReinvocationPolicy != never {
+				t.Errorf("expected the webhook's own policy Never to win, got %v", wh.ReinvocationPolicy)
+			}
+		case strings.Contains(wh.Name, "withoutown"):
+			if wh.ReinvocationPolicy == nil || *wh.ReinvocationPolicy != admissionregistration.IfNeededReinvocationPolicy {
+				t.Errorf("expected the default policy IfNeeded to apply, got %v", wh.ReinvocationPolicy)
+			}
+		}
+	}
+}
+
+func TestDefaultReinvocationPolicyIgnoredForValidating(t *testing.T) {
+	o := NewGenerator(
+		WithDefaultReinvocationPolicy(admissionregistration.IfNeededReinvocationPolicy),
+		WithWebhook(testValidatingWebhook("validatepods.example.com", "/validate-pods")),
+	)
+	if _, err := o.whConfigs(); err != nil {
+		t.Fatalf("expected whConfigs to succeed, got %v", err)
+	}
+}
+
+func TestValidateReinvocationPolicy(t *testing.T) {
+	o := NewGenerator(
+		WithDefaultReinvocationPolicy("Sometimes"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an invalid default reinvocationPolicy to fail validation")
+	}
+}
+
+func TestWebhookNames(t *testing.T) {
+	mutating := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	mutating.Order = 1
+	validating := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+
+	o := NewGenerator(WithWebhook(mutating, validating))
+	names, err := o.WebhookNames()
+	if err != nil {
+		t.Fatalf("expected WebhookNames to succeed, got %v", err)
+	}
+	// mutating names carry the zero-padded order prefix from defaulting
+	if names["/mutate-pods"] != "01-mutatepods.example.com" {
+		t.Errorf("expected the order-prefixed mutating name, got %q", names["/mutate-pods"])
+	}
+	if names["/validate-pods"] != "validatepods.example.com" {
+		t.Errorf("expected the validating name unchanged, got %q", names["/validate-pods"])
+	}
+}
+
+func TestWebhookNamesInvalidOptions(t *testing.T) {
+	o := NewGenerator()
+	if _, err := o.WebhookNames(); err == nil {
+		t.Error("expected WebhookNames to fail for an empty registry")
+	}
+}
+
+func TestOmitEmptyCABundle(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	cc, err := o.getClientConfig()
+	if err != nil {
+		t.Fatalf("expected getClientConfig to succeed, got %v", err)
+	}
+	// the default emits an explicit empty placeholder for the provisioner
+	if cc.CABundle == nil || len(cc.CABundle) != 0 {
+		t.Errorf("expected an explicit empty CABundle by default, got %v", cc.CABundle)
+	}
+
+	o = NewGenerator(
+		WithHost("localhost"),
+		WithOmitEmptyCABundle(true),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	cc, err = o.getClientConfig()
+	if err != nil {
+		t.Fatalf("expected getClientConfig to succeed, got %v", err)
+	}
+	if cc.CABundle != nil {
+		t.Errorf("expected the CABundle field to be omitted, got %v", cc.CABundle)
+	}
+}
+
+func TestRequireFailClosed(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithRequireFailClosed(true),
+		WithWebhook(wh),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected a validating webhook without a failure policy to fail validation")
+	}
+
+	ignore := admissionregistration.Ignore
+	wh.FailurePolicy = &ignore
+	if err := o.Validate(); err == nil {
+		t.Error("expected an explicitly fail-open validating webhook to fail validation")
+	}
+
+	fail := admissionregistration.Fail
+	wh.FailurePolicy = &fail
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected a fail-closed validating webhook to pass, got %v", err)
+	}
+}
+
+func TestRequireFailClosedExemptsMutating(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithRequireFailClosed(true),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected fail-open mutating webhooks to be exempt, got %v", err)
+	}
+}
+
+func TestLabelRouting(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithLabelRouting(true),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	mwc, ok := configs[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if !ok {
+		t.Fatalf("expected a MutatingWebhookConfiguration, got %T", configs[0])
+	}
+	wh := mwc.Webhooks[0]
+	if wh.ClientConfig.Service == nil || wh.ClientConfig.Service.Path == nil || *wh.ClientConfig.Service.Path != "/webhook" {
+		t.Errorf("expected all webhooks to share the /webhook path, got %+v", wh.ClientConfig.Service)
+	}
+	if wh.ObjectSelector == nil || wh.ObjectSelector.MatchLabels["webhook.routing/handler"] != "00-mutatepods.example.com" {
+		t.Errorf("expected an objectSelector on the routing label, got %+v", wh.ObjectSelector)
+	}
+}
+
+func TestLabelRoutingVersionGate(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithLabelRouting(true),
+		WithTargetK8sVersion("1.14"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if _, err := o.whConfigs(); err == nil {
+		t.Error("expected label routing to be rejected for kubernetes 1.14")
+	}
+}
+
+func TestServePathOverride(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/internal/mutate-pods")
+	servePath := "/external/mutate-pods"
+	wh.ServePath = &servePath
+
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	mwc, ok := configs[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if !ok {
+		t.Fatalf("expected a MutatingWebhookConfiguration, got %T", configs[0])
+	}
+	cc := mwc.Webhooks[0].ClientConfig
+	if cc.Service == nil || cc.Service.Path == nil || *cc.Service.Path != servePath {
+		t.Errorf("expected the serve path override in the client config, got %+v", cc.Service)
+	}
+	// the registry key keeps the internal routing path
+	if _, found := o.registry["/internal/mutate-pods"]; !found {
+		t.Error("expected the registry key to stay the internal path")
+	}
+}
+
+func TestServePathOverrideValidation(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	servePath := "no-leading-slash"
+	wh.ServePath = &servePath
+	if err := wh.Validate(); err == nil {
+		t.Error("expected a serve path without a leading / to fail validation")
+	}
+}
+
+func TestBypassAnnotation(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.BypassAnnotationKey = "webhook.example.com/bypass"
+	wh.BypassAnnotationValue = "true"
+
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	mwc, ok := configs[0].(*admissionregistration.MutatingWebhookConfiguration)
+	if !ok {
+		t.Fatalf("expected a MutatingWebhookConfiguration, got %T", configs[0])
+	}
+	objectSelector := mwc.Webhooks[0].ObjectSelector
+	if objectSelector == nil {
+		t.Fatal("expected an objectSelector with the bypass requirement")
+	}
+	selector, err := metav1.LabelSelectorAsSelector(objectSelector)
+	if err != nil {
+		t.Fatalf("expected the selector to convert, got %v", err)
+	}
+	if selector.Matches(labels.Set{"webhook.example.com/bypass": "true"}) {
+		t.Error("expected bypass-annotated objects to be excluded")
+	}
+	if !selector.Matches(labels.Set{}) {
+		t.Error("expected unannotated objects to match")
+	}
+	if !selector.Matches(labels.Set{"webhook.example.com/bypass": "false"}) {
+		t.Error("expected objects with a different value to match")
+	}
+}
+
+func TestBypassAnnotationValidation(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.BypassAnnotationKey = "webhook.example.com/bypass"
+	if err := wh.Validate(); err == nil {
+		t.Error("expected a bypass key without a value to fail validation")
+	}
+}
+
+func TestServiceTypeOverride(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+			Type:      corev1.ServiceTypeLoadBalancer,
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if svc, ok := obj.(*corev1.Service); ok {
+			if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				t.Errorf("expected a LoadBalancer service, got %q", svc.Spec.Type)
+			}
+			return
+		}
+	}
+	t.Fatal("expected a Service to be generated")
+}
+
+func TestServiceTypeValidation(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+			Type:      corev1.ServiceType("ExternalName"),
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an unsupported service type to fail validation")
+	}
+}
+
+func TestWebhookConfigGroup(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	if apiVersion := configs[0].(*admissionregistration.MutatingWebhookConfiguration).APIVersion; apiVersion != "admissionregistration.k8s.io/v1beta1" {
+		t.Errorf("expected the upstream group by default, got %q", apiVersion)
+	}
+
+	o = NewGenerator(
+		WithHost("localhost"),
+		WithWebhookConfigGroup("admission.vendor.example.com"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err = o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	if apiVersion := configs[0].(*admissionregistration.MutatingWebhookConfiguration).APIVersion; apiVersion != "admission.vendor.example.com/v1beta1" {
+		t.Errorf("expected the vendor group, got %q", apiVersion)
+	}
+}
+
+func TestWebhookConfigGroupValidation(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhookConfigGroup("Not_A_Subdomain"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected a non-subdomain group to fail validation")
+	}
+}
+
+func TestExcludeOwnNamespace(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithExcludeOwnNamespace("system"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	webhook := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks[0]
+	if webhook.NamespaceSelector == nil {
+		t.Fatal("expected a namespaceSelector to be injected")
+	}
+	selector, err := metav1.LabelSelectorAsSelector(webhook.NamespaceSelector)
+	if err != nil {
+		t.Fatalf("expected the selector to convert, got %v", err)
+	}
+	if selector.Matches(labels.Set{namespaceNameLabel: "system"}) {
+		t.Error("expected the webhook's own namespace to be excluded")
+	}
+	if !selector.Matches(labels.Set{namespaceNameLabel: "default"}) {
+		t.Error("expected other namespaces to match")
+	}
+}
+
+func TestExcludeOwnNamespacePreservesSelector(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"env": "prod"},
+	}
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithExcludeOwnNamespace("system"),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	webhook := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks[0]
+	selector, err := metav1.LabelSelectorAsSelector(webhook.NamespaceSelector)
+	if err != nil {
+		t.Fatalf("expected the selector to convert, got %v", err)
+	}
+	if !selector.Matches(labels.Set{"env": "prod", namespaceNameLabel: "default"}) {
+		t.Error("expected the registered selector to be preserved")
+	}
+	if selector.Matches(labels.Set{"env": "prod", namespaceNameLabel: "system"}) {
+		t.Error("expected the own-namespace exclusion to be appended")
+	}
+	if len(wh.NamespaceSelector.MatchExpressions) != 0 {
+		t.Error("expected the registered webhook's selector to be left untouched")
+	}
+}
+
+func TestControlPlaneExclusionMergedIntoSelector(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"env": "prod"},
+	}
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	webhook := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks[0]
+	selector, err := metav1.LabelSelectorAsSelector(webhook.NamespaceSelector)
+	if err != nil {
+		t.Fatalf("expected the selector to convert, got %v", err)
+	}
+	if !selector.Matches(labels.Set{"env": "prod"}) {
+		t.Error("expected the registered selector to be preserved")
+	}
+	if selector.Matches(labels.Set{"env": "prod", controlPlaneLabel: "controller-manager"}) {
+		t.Error("expected the control-plane exclusion to be merged into the registered selector")
+	}
+	if len(wh.NamespaceSelector.MatchExpressions) != 0 {
+		t.Error("expected the registered webhook's selector to be left untouched")
+	}
+}
+
+func TestControlPlaneExclusionNotDuplicated(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.NamespaceSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: controlPlaneLabel, Operator: metav1.LabelSelectorOpDoesNotExist},
+		},
+	}
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	webhook := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks[0]
+	if len(webhook.NamespaceSelector.MatchExpressions) != 1 {
+		t.Errorf("expected the control-plane requirement not to be duplicated, got %v", webhook.NamespaceSelector.MatchExpressions)
+	}
+}
+
+func TestExcludeOwnNamespaceVersionGate(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithTargetK8sVersion("1.20"),
+		WithExcludeOwnNamespace("system"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if _, err := o.whConfigs(); err == nil {
+		t.Error("expected the namespace name label to be rejected before 1.21")
+	}
+}
+
+func TestExcludeOwnNamespaceValidation(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithExcludeOwnNamespace(""),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an empty own namespace to fail validation")
+	}
+}
+
+func TestWithScopeSelectors(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods").
+		WithScopeSelectors(
+			map[string]string{"env": "prod"},
+			map[string]string{"app": "payments"},
+		)
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	webhook := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks[0]
+	if webhook.NamespaceSelector == nil || webhook.NamespaceSelector.MatchLabels["env"] != "prod" {
+		t.Errorf("expected the namespaceSelector to be set, got %v", webhook.NamespaceSelector)
+	}
+	if webhook.ObjectSelector == nil || webhook.ObjectSelector.MatchLabels["app"] != "payments" {
+		t.Errorf("expected the objectSelector to be set, got %v", webhook.ObjectSelector)
+	}
+}
+
+func TestWithScopeSelectorsVersionGate(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods").
+		WithScopeSelectors(nil, map[string]string{"app": "payments"})
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithTargetK8sVersion("1.14"),
+		WithWebhook(wh),
+	)
+	if _, err := o.whConfigs(); err == nil {
+		t.Error("expected an objectSelector to be rejected before 1.15")
+	}
+}
+
+func TestWithScopeSelectorsValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		nsLabels  map[string]string
+		objLabels map[string]string
+	}{
+		{
+			name:     "invalid namespace label key",
+			nsLabels: map[string]string{"-invalid-": "prod"},
+		},
+		{
+			name:      "invalid object label value",
+			objLabels: map[string]string{"app": "not a valid value"},
+		},
+	}
+	for _, test := range tests {
+		wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods").
+			WithScopeSelectors(test.nsLabels, test.objLabels)
+		if err := wh.Validate(); err == nil {
+			t.Errorf("%v: expected validation to fail", test.name)
+		}
+	}
+}
+
+func TestStrictNoHostDefaulting(t *testing.T) {
+	o := NewGenerator(
+		WithStrict(),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if o.host != nil {
+		t.Errorf("expected strict mode to not default the host, got %q", *o.host)
+	}
+	if _, err := o.Generate(); err == nil {
+		t.Error("expected Generate to fail without a host or service in strict mode")
+	}
+
+	// an explicit host is still fine
+	o = NewGenerator(
+		WithStrict(),
+		WithHost("webhook.example.com"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if _, err := o.Generate(); err != nil {
+		t.Errorf("expected Generate with an explicit host to succeed, got %v", err)
+	}
+}
+
+func TestAnnotatePaths(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithAnnotatePaths(),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	annotations := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Annotations
+	value, found := annotations["webhook.kubebuilder.io/paths"]
+	if !found {
+		t.Fatalf("expected the paths annotation, got %v", annotations)
+	}
+	paths := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &paths); err != nil {
+		t.Fatalf("expected the annotation to be a JSON map, got %v", err)
+	}
+	if paths["00-mutatepods.example.com"] != "/mutate-pods" {
+		t.Errorf("expected the webhook name to map to its path, got %v", paths)
+	}
+}
+
+func TestNoPathsAnnotationByDefault(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	annotations := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Annotations
+	if _, found := annotations["webhook.kubebuilder.io/paths"]; found {
+		t.Errorf("expected no paths annotation without opt-in, got %v", annotations)
+	}
+}
+
+func TestMutatingWebhookExplicitOrdering(t *testing.T) {
+	inject := testMutatingWebhook("inject.example.com", "/inject-pods")
+	inject.Order = 2
+	defaulting := testMutatingWebhook("default.example.com", "/default-pods")
+	defaulting.Order = 1
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(inject),
+		WithWebhook(defaulting),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	webhooks := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks
+	if len(webhooks) != 2 {
+		t.Fatalf("expected two webhook entries, got %d", len(webhooks))
+	}
+	if webhooks[0].Name != "01-default.example.com" || webhooks[1].Name != "02-inject.example.com" {
+		t.Errorf("expected the entries in declared order, got %q, %q", webhooks[0].Name, webhooks[1].Name)
+	}
+}
+
+func TestServiceNameValidation(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook_service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	err := o.Validate()
+	if err == nil {
+		t.Fatal("expected an underscore in the service name to fail validation")
+	}
+	if !strings.Contains(err.Error(), `service name "webhook_service"`) {
+		t.Errorf("expected the error to name the invalid field, got %v", err)
+	}
+
+	o = NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "Sys.tem",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	err = o.Validate()
+	if err == nil {
+		t.Fatal("expected an invalid service namespace to fail validation")
+	}
+	if !strings.Contains(err.Error(), `service namespace "Sys.tem"`) {
+		t.Errorf("expected the error to name the invalid field, got %v", err)
+	}
+}
+
+func TestGenerateApplyOrder(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	serviceIndex, mutatingIndex, validatingIndex := -1, -1, -1
+	for i, obj := range objects {
+		switch obj.(type) {
+		case *corev1.Service:
+			serviceIndex = i
+		case *admissionregistration.MutatingWebhookConfiguration:
+			mutatingIndex = i
+		case *admissionregistration.ValidatingWebhookConfiguration:
+			validatingIndex = i
+		}
+	}
+	if serviceIndex < 0 || mutatingIndex < 0 || validatingIndex < 0 {
+		t.Fatalf("expected the service and both configurations to be generated, got %v", objects)
+	}
+	// Applying the slice front to back must create the backend before the
+	// webhook configurations referencing it.
+	if serviceIndex > mutatingIndex || serviceIndex > validatingIndex {
+		t.Errorf("expected the Service before the webhook configurations, got service at %d, configs at %d and %d",
+			serviceIndex, mutatingIndex, validatingIndex)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func signBody(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body)) // nolint: errcheck
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithHMACSecret("shared-secret"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	handled := false
+	handler := o.VerifyHMAC(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		handled = true
+	}))
+
+	body := `{"request":{}}`
+
+	// a correctly signed request reaches the handler
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/mutate-pods", strings.NewReader(body))
+	req.Header.Set(hmacSignatureHeader, signBody("shared-secret", body))
+	handler.ServeHTTP(recorder, req)
+	if !handled || recorder.Code != http.StatusOK {
+		t.Errorf("expected a signed request to be accepted, got status %d", recorder.Code)
+	}
+
+	// a tampered body no longer matches the signature
+	handled = false
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/mutate-pods", strings.NewReader(body+"tampered"))
+	req.Header.Set(hmacSignatureHeader, signBody("shared-secret", body))
+	handler.ServeHTTP(recorder, req)
+	if handled || recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected a tampered request to be rejected with 401, got status %d", recorder.Code)
+	}
+
+	// a signature under the wrong secret is rejected
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/mutate-pods", strings.NewReader(body))
+	req.Header.Set(hmacSignatureHeader, signBody("wrong-secret", body))
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected a wrongly signed request to be rejected with 401, got status %d", recorder.Code)
+	}
+
+	// a missing signature is rejected
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/mutate-pods", strings.NewReader(body)))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unsigned request to be rejected with 401, got status %d", recorder.Code)
+	}
+}
+
+func TestVerifyHMACDisabled(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	inner := http.NotFoundHandler()
+	handler := o.VerifyHMAC(inner)
+	// The handlers are HandlerFuncs, so compare the function pointers
+	// instead of the uncomparable interface values.
+	if reflect.ValueOf(handler).Pointer() != reflect.ValueOf(inner).Pointer() {
+		t.Error("expected the handler to be returned unchanged without a secret")
+	}
+}
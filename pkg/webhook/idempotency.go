@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+// defaultIdempotencyTTL is how long a cached admission response is replayed
+// for retries of the same request UID. The API server retries timed-out
+// requests quickly, so a short window is enough.
+const defaultIdempotencyTTL = 30 * time.Second
+
+// IdempotencyStore caches admission responses by request UID, so a retry of
+// a timed-out request replays the recorded response instead of running a
+// non-idempotent mutating handler twice.
+type IdempotencyStore struct {
+	ttl     time.Duration
+	entries sync.Map
+}
+
+// idempotencyEntry is the cached response for one request UID.
+type idempotencyEntry struct {
+	contentType string
+	body        []byte
+}
+
+// NewIdempotencyStore creates a store evicting cached responses after ttl.
+// A non-positive ttl is defaulted to 30s.
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &IdempotencyStore{ttl: ttl}
+}
+
+// get returns the cached response for the UID, if present.
+func (s *IdempotencyStore) get(uid apitypes.UID) (idempotencyEntry, bool) {
+	value, found := s.entries.Load(uid)
+	if !found {
+		return idempotencyEntry{}, false
+	}
+	return value.(idempotencyEntry), true
+}
+
+// put caches the response for the UID and schedules its eviction.
+func (s *IdempotencyStore) put(uid apitypes.UID, entry idempotencyEntry) {
+	s.entries.Store(uid, entry)
+	time.AfterFunc(s.ttl, func() {
+		s.entries.Delete(uid)
+	})
+}
+
+// Middleware wraps the handler so that a retried admission request with a
+// UID seen within the TTL gets the recorded response instead of invoking the
+// handler again.
+func (s *IdempotencyStore) Middleware(next http.Handler) http.Handler {
+	return idempotencyMiddleware(s, next)
+}
+
+// idempotencyMiddleware replays cached responses for request UIDs seen
+// within the TTL of the store.
+func idempotencyMiddleware(store *IdempotencyStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Body == nil {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(rw, "unable to read request body", http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		uid := requestUID(body)
+		if len(uid) == 0 {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		if entry, found := store.get(uid); found {
+			if len(entry.contentType) != 0 {
+				rw.Header().Set("Content-Type", entry.contentType)
+			}
+			rw.Write(entry.body) // nolint: errcheck
+			return
+		}
+
+		recorder := &recordingResponseWriter{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		// Only successful responses are worth replaying; a failed attempt
+		// should be retried for real.
+		if recorder.status == http.StatusOK {
+			store.put(uid, idempotencyEntry{
+				contentType: rw.Header().Get("Content-Type"),
+				body:        recorder.body.Bytes(),
+			})
+		}
+	})
+}
+
+// requestUID extracts the admission request UID from the review body,
+// returning the empty UID if the body is no admission review.
+func requestUID(body []byte) apitypes.UID {
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		return ""
+	}
+	return review.Request.UID
+}
+
+// recordingResponseWriter passes writes through while keeping a copy of the
+// body and the status code.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recordingResponseWriter) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recordingResponseWriter) Write(content []byte) (int, error) {
+	r.body.Write(content) // nolint: errcheck
+	return r.ResponseWriter.Write(content)
+}
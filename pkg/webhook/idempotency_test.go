@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func admissionReviewBody(t *testing.T, uid string) *bytes.Reader {
+	review := admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{},
+	}
+	review.Request.UID = apitypes.UID(uid)
+	content, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("expected marshaling to succeed, got %v", err)
+	}
+	return bytes.NewReader(content)
+}
+
+func TestIdempotencyMiddlewareReplay(t *testing.T) {
+	store := NewIdempotencyStore(time.Minute)
+	invocations := 0
+	handler := store.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		invocations++
+		fmt.Fprintf(rw, "response %d", invocations) // nolint: errcheck
+	}))
+
+	// the retried request replays the first response without reinvoking the
+	// handler
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "retry-uid")))
+	retry := httptest.NewRecorder()
+	handler.ServeHTTP(retry, httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "retry-uid")))
+
+	if invocations != 1 {
+		t.Errorf("expected the handler to run once, got %d invocations", invocations)
+	}
+	if first.Body.String() != "response 1" || retry.Body.String() != "response 1" {
+		t.Errorf("expected the retry to replay the first response, got %q and %q", first.Body, retry.Body)
+	}
+
+	// a different UID is a different request
+	other := httptest.NewRecorder()
+	handler.ServeHTTP(other, httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "other-uid")))
+	if invocations != 2 {
+		t.Errorf("expected a different UID to reach the handler, got %d invocations", invocations)
+	}
+}
+
+func TestIdempotencyMiddlewareTTL(t *testing.T) {
+	store := NewIdempotencyStore(10 * time.Millisecond)
+	invocations := 0
+	handler := store.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		invocations++
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "expiring-uid")))
+	time.Sleep(50 * time.Millisecond)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "expiring-uid")))
+
+	if invocations != 2 {
+		t.Errorf("expected the cached response to be evicted after the TTL, got %d invocations", invocations)
+	}
+}
+
+func TestIdempotencyMiddlewareErrorsNotCached(t *testing.T) {
+	store := NewIdempotencyStore(time.Minute)
+	invocations := 0
+	handler := store.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		invocations++
+		if invocations == 1 {
+			http.Error(rw, "transient failure", http.StatusInternalServerError)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "failed-uid")))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/mutate-pods", admissionReviewBody(t, "failed-uid")))
+
+	if invocations != 2 {
+		t.Errorf("expected a failed response to not be cached, got %d invocations", invocations)
+	}
+}
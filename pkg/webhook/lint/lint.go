@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint checks webhook configuration entries against configurable
+// policy rules that go beyond API validity, e.g. "all mutating webhooks must
+// declare sideEffects: None".
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// LintResult is one finding of a lint rule.
+type LintResult struct {
+	// Rule is the name of the rule that produced the finding.
+	Rule string
+	// Path is the registry path of the webhook the finding is about.
+	Path string
+	// Webhook is the name of the webhook the finding is about.
+	Webhook string
+	// Message describes the finding.
+	Message string
+}
+
+// LintRule checks a single webhook entry and reports zero or more findings.
+type LintRule interface {
+	// Name is the name the rule's findings are reported under.
+	Name() string
+	// Check returns a message per violation found in the webhook.
+	Check(webhook admissionregistration.Webhook) []string
+}
+
+// rule is a LintRule built from a name and a check function.
+type rule struct {
+	name  string
+	check func(webhook admissionregistration.Webhook) []string
+}
+
+func (r *rule) Name() string { return r.name }
+
+func (r *rule) Check(webhook admissionregistration.Webhook) []string { return r.check(webhook) }
+
+// NewRule creates a LintRule from a name and a check function, for
+// user-defined policies.
+func NewRule(name string, check func(webhook admissionregistration.Webhook) []string) LintRule {
+	return &rule{name: name, check: check}
+}
+
+// NoWildcardOperations flags rules using the wildcard operation '*'.
+var NoWildcardOperations = NewRule("NoWildcardOperations", func(webhook admissionregistration.Webhook) []string {
+	var findings []string
+	for _, rule := range webhook.Rules {
+		for _, op := range rule.Operations {
+			if op == admissionregistration.OperationAll {
+				findings = append(findings, "uses the wildcard operation '*'; list the operations explicitly")
+			}
+		}
+	}
+	return findings
+})
+
+// SideEffectsNoneForMutating flags webhooks that don't declare
+// sideEffects: None. It is meant to be run against the entries of a
+// MutatingWebhookConfiguration.
+var SideEffectsNoneForMutating = NewRule("SideEffectsNoneForMutating", func(webhook admissionregistration.Webhook) []string {
+	if webhook.SideEffects == nil || *webhook.SideEffects != admissionregistration.SideEffectClassNone {
+		return []string{"should declare sideEffects: None"}
+	}
+	return nil
+})
+
+// TimeoutBelowThirty flags webhooks with a timeout of 30 seconds or more.
+var TimeoutBelowThirty = NewRule("TimeoutBelowThirty", func(webhook admissionregistration.Webhook) []string {
+	if webhook.TimeoutSeconds != nil && *webhook.TimeoutSeconds >= 30 {
+		return []string{fmt.Sprintf("timeout of %ds should be below 30s", *webhook.TimeoutSeconds)}
+	}
+	return nil
+})
+
+// NameIsDNSSubdomain flags webhook names that are not valid DNS subdomains.
+var NameIsDNSSubdomain = NewRule("NameIsDNSSubdomain", func(webhook admissionregistration.Webhook) []string {
+	var findings []string
+	for _, msg := range validation.IsDNS1123Subdomain(webhook.Name) {
+		findings = append(findings, fmt.Sprintf("name is not a valid DNS subdomain: %s", msg))
+	}
+	return findings
+})
+
+// NamespaceSelectorPresent flags webhooks with a wildcard operation but no
+// namespace selector to bound the blast radius.
+var NamespaceSelectorPresent = NewRule("NamespaceSelectorPresent", func(webhook admissionregistration.Webhook) []string {
+	if webhook.NamespaceSelector != nil {
+		return nil
+	}
+	for _, rule := range webhook.Rules {
+		for _, op := range rule.Operations {
+			if op == admissionregistration.OperationAll {
+				return []string{"uses the wildcard operation '*' without a namespaceSelector"}
+			}
+		}
+	}
+	return nil
+})
+
+// Linter runs lint rules over webhook entries.
+type Linter struct {
+	rules []LintRule
+}
+
+// NewLinter creates a linter preloaded with the built-in rules.
+func NewLinter() *Linter {
+	return &Linter{
+		rules: []LintRule{
+			NoWildcardOperations,
+			SideEffectsNoneForMutating,
+			TimeoutBelowThirty,
+			NameIsDNSSubdomain,
+			NamespaceSelectorPresent,
+		},
+	}
+}
+
+// AddRule registers an additional, user-defined rule.
+func (l *Linter) AddRule(rule LintRule) {
+	l.rules = append(l.rules, rule)
+}
+
+// RunAll runs every rule against every webhook in the registry, which maps
+// the registered path to the webhook entry. The results are sorted by path
+// and rule name so the output is deterministic.
+func (l *Linter) RunAll(registry map[string]admissionregistration.Webhook) []LintResult {
+	var results []LintResult
+	for path, webhook := range registry {
+		for _, rule := range l.rules {
+			for _, message := range rule.Check(webhook) {
+				results = append(results, LintResult{
+					Rule:    rule.Name(),
+					Path:    path,
+					Webhook: webhook.Name,
+					Message: message,
+				})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Path != results[j].Path {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].Rule < results[j].Rule
+	})
+	return results
+}
+
+// String formats the result as a one-line finding.
+func (r LintResult) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%s: webhook %q (path %s) %s", r.Rule, r.Webhook, r.Path, r.Message))
+}
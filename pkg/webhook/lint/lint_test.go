@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func cleanWebhook(name string) admissionregistration.Webhook {
+	sideEffects := admissionregistration.SideEffectClassNone
+	return admissionregistration.Webhook{
+		Name:        name,
+		SideEffects: &sideEffects,
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"webhooks": "enabled"},
+		},
+		Rules: []admissionregistration.RuleWithOperations{
+			{
+				Operations: []admissionregistration.OperationType{admissionregistration.Create},
+				Rule: admissionregistration.Rule{
+					APIGroups:   []string{"apps"},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"deployments"},
+				},
+			},
+		},
+	}
+}
+
+func TestRunAllClean(t *testing.T) {
+	results := NewLinter().RunAll(map[string]admissionregistration.Webhook{
+		"/mutate-deployments": cleanWebhook("mutatedeployments.example.com"),
+	})
+	if len(results) != 0 {
+		t.Errorf("expected no findings for a clean webhook, got %v", results)
+	}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	timeout := int32(30)
+	wildcard := cleanWebhook("wildcard.example.com")
+	wildcard.Rules[0].Operations = []admissionregistration.OperationType{admissionregistration.OperationAll}
+	wildcard.NamespaceSelector = nil
+
+	badName := cleanWebhook("Not_A_Subdomain")
+	slowWebhook := cleanWebhook("slow.example.com")
+	slowWebhook.TimeoutSeconds = &timeout
+	someSideEffects := admissionregistration.SideEffectClassSome
+	sideEffects := cleanWebhook("sideeffects.example.com")
+	sideEffects.SideEffects = &someSideEffects
+
+	results := NewLinter().RunAll(map[string]admissionregistration.Webhook{
+		"/a-wildcard":    wildcard,
+		"/b-name":        badName,
+		"/c-timeout":     slowWebhook,
+		"/d-sideeffects": sideEffects,
+	})
+
+	found := map[string]bool{}
+	for _, result := range results {
+		found[result.Path+"/"+result.Rule] = true
+	}
+	expected := []string{
+		"/a-wildcard/NoWildcardOperations",
+		"/a-wildcard/NamespaceSelectorPresent",
+		"/b-name/NameIsDNSSubdomain",
+		"/c-timeout/TimeoutBelowThirty",
+		"/d-sideeffects/SideEffectsNoneForMutating",
+	}
+	for _, key := range expected {
+		if !found[key] {
+			t.Errorf("expected finding %s, got %v", key, results)
+		}
+	}
+}
+
+func TestAddRule(t *testing.T) {
+	linter := NewLinter()
+	linter.AddRule(NewRule("NoExampleDotCom", func(webhook admissionregistration.Webhook) []string {
+		if webhook.Name == "mutatedeployments.example.com" {
+			return []string{"example.com names are reserved"}
+		}
+		return nil
+	}))
+
+	results := linter.RunAll(map[string]admissionregistration.Webhook{
+		"/mutate-deployments": cleanWebhook("mutatedeployments.example.com"),
+	})
+	if len(results) != 1 || results[0].Rule != "NoExampleDotCom" {
+		t.Errorf("expected one finding from the custom rule, got %v", results)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+// Recorder counts notable events during webhook configuration generation.
+// Implementations can bridge the counters to a metrics system, e.g.
+// prometheus counters, to alert when generation starts failing.
+type Recorder interface {
+	// IncGenerated is invoked once per webhook entry that is successfully
+	// generated. webhookType is the type of the webhook, e.g. mutating or
+	// validating.
+	IncGenerated(webhookType string)
+	// IncValidationError is invoked when the webhook registered under path
+	// fails validation during generation.
+	IncValidationError(path string)
+}
+
+// WithMetrics sets the recorder that generation increments.
+// If unset, a no-op recorder is used.
+func WithMetrics(m Recorder) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.metrics = m
+	}
+}
+
+// noopRecorder is a Recorder that does nothing.
+type noopRecorder struct{}
+
+var _ Recorder = noopRecorder{}
+
+func (noopRecorder) IncGenerated(string)       {}
+func (noopRecorder) IncValidationError(string) {}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// This file migrates existing v1beta1 webhook configurations to the v1 API.
+// Fields that v1 requires but v1beta1 leaves optional are filled in:
+// sideEffects defaults to NoneOnDryRun and admissionReviewVersions defaults
+// to ["v1", "v1beta1"]. Fields whose API default changed between the
+// versions (failurePolicy: Ignore to Fail, matchPolicy: Exact to Equivalent)
+// are pinned to the v1beta1 default when unset, so the migrated
+// configuration behaves like the original rather than silently adopting the
+// new defaults.
+
+// defaultAdmissionReviewVersions is the admissionReviewVersions set on
+// migrated webhooks that don't declare their own.
+var defaultAdmissionReviewVersions = []string{"v1", "v1beta1"}
+
+// MigrateMutatingV1Beta1ToV1 converts an existing v1beta1
+// MutatingWebhookConfiguration to the v1 API.
+func MigrateMutatingV1Beta1ToV1(old *admissionregistration.MutatingWebhookConfiguration) (*admissionregistrationv1.MutatingWebhookConfiguration, error) {
+	if old == nil {
+		return nil, errors.New("cannot migrate a nil MutatingWebhookConfiguration")
+	}
+	migrated := &admissionregistrationv1.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "MutatingWebhookConfiguration",
+		},
+	}
+	old.ObjectMeta.DeepCopyInto(&migrated.ObjectMeta)
+	for i := range old.Webhooks {
+		vwh, err := migrateWebhookV1(&old.Webhooks[i])
+		if err != nil {
+			return nil, err
+		}
+		mwh := admissionregistrationv1.MutatingWebhook{
+			Name:                    vwh.Name,
+			ClientConfig:            vwh.ClientConfig,
+			Rules:                   vwh.Rules,
+			FailurePolicy:           vwh.FailurePolicy,
+			MatchPolicy:             vwh.MatchPolicy,
+			NamespaceSelector:       vwh.NamespaceSelector,
+			ObjectSelector:          vwh.ObjectSelector,
+			SideEffects:             vwh.SideEffects,
+			TimeoutSeconds:          vwh.TimeoutSeconds,
+			AdmissionReviewVersions: vwh.AdmissionReviewVersions,
+		}
+		if old.Webhooks[i].ReinvocationPolicy != nil {
+			policy := admissionregistrationv1.ReinvocationPolicyType(*old.Webhooks[i].ReinvocationPolicy)
+			mwh.ReinvocationPolicy = &policy
+		}
+		migrated.Webhooks = append(migrated.Webhooks, mwh)
+	}
+	return migrated, nil
+}
+
+// MigrateValidatingV1Beta1ToV1 converts an existing v1beta1
+// ValidatingWebhookConfiguration to the v1 API.
+func MigrateValidatingV1Beta1ToV1(old *admissionregistration.ValidatingWebhookConfiguration) (*admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	if old == nil {
+		return nil, errors.New("cannot migrate a nil ValidatingWebhookConfiguration")
+	}
+	migrated := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+	}
+	old.ObjectMeta.DeepCopyInto(&migrated.ObjectMeta)
+	for i := range old.Webhooks {
+		vwh, err := migrateWebhookV1(&old.Webhooks[i])
+		if err != nil {
+			return nil, err
+		}
+		migrated.Webhooks = append(migrated.Webhooks, *vwh)
+	}
+	return migrated, nil
+}
+
+// migrateWebhookV1 converts one v1beta1 webhook entry to a v1
+// ValidatingWebhook, which carries the fields common to both v1 entry types.
+func migrateWebhookV1(old *admissionregistration.Webhook) (*admissionregistrationv1.ValidatingWebhook, error) {
+	webhook := &admissionregistrationv1.ValidatingWebhook{
+		Name:                    old.Name,
+		ClientConfig:            migrateClientConfigV1(&old.ClientConfig),
+		Rules:                   convertRulesToV1(old.Rules, nil),
+		NamespaceSelector:       old.NamespaceSelector.DeepCopy(),
+		ObjectSelector:          old.ObjectSelector.DeepCopy(),
+		AdmissionReviewVersions: append([]string(nil), old.AdmissionReviewVersions...),
+	}
+	if len(webhook.AdmissionReviewVersions) == 0 {
+		webhook.AdmissionReviewVersions = append([]string(nil), defaultAdmissionReviewVersions...)
+	}
+	// Preserve the v1beta1 API defaults instead of inheriting the changed v1
+	// ones.
+	failurePolicy := admissionregistrationv1.FailurePolicyType(admissionregistration.Ignore)
+	if old.FailurePolicy != nil {
+		failurePolicy = admissionregistrationv1.FailurePolicyType(*old.FailurePolicy)
+	}
+	webhook.FailurePolicy = &failurePolicy
+	matchPolicy := admissionregistrationv1.MatchPolicyType(admissionregistration.Exact)
+	if old.MatchPolicy != nil {
+		matchPolicy = admissionregistrationv1.MatchPolicyType(*old.MatchPolicy)
+	}
+	webhook.MatchPolicy = &matchPolicy
+	sideEffects := admissionregistrationv1.SideEffectClassNoneOnDryRun
+	if old.SideEffects != nil {
+		switch *old.SideEffects {
+		case admissionregistration.SideEffectClassNone, admissionregistration.SideEffectClassNoneOnDryRun:
+			sideEffects = admissionregistrationv1.SideEffectClass(*old.SideEffects)
+		default:
+			return nil, fmt.Errorf("webhook %q has sideEffects %q, which v1 does not allow; declare None or NoneOnDryRun", old.Name, *old.SideEffects)
+		}
+	}
+	webhook.SideEffects = &sideEffects
+	if old.TimeoutSeconds != nil {
+		timeout := *old.TimeoutSeconds
+		webhook.TimeoutSeconds = &timeout
+	}
+	return webhook, nil
+}
+
+// migrateClientConfigV1 converts the v1beta1 client config to its v1
+// equivalent.
+func migrateClientConfigV1(old *admissionregistration.WebhookClientConfig) admissionregistrationv1.WebhookClientConfig {
+	cc := admissionregistrationv1.WebhookClientConfig{
+		CABundle: append([]byte(nil), old.CABundle...),
+	}
+	if old.URL != nil {
+		urlString := *old.URL
+		cc.URL = &urlString
+	}
+	if old.Service != nil {
+		cc.Service = &admissionregistrationv1.ServiceReference{
+			Namespace: old.Service.Namespace,
+			Name:      old.Service.Name,
+		}
+		if old.Service.Path != nil {
+			path := *old.Service.Path
+			cc.Service.Path = &path
+		}
+		if old.Service.Port != nil {
+			port := *old.Service.Port
+			cc.Service.Port = &port
+		}
+	}
+	return cc
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// v1beta1Fixture is a webhook configuration as kubebuilder emitted it for
+// Kubernetes 1.13: no sideEffects, no admissionReviewVersions, relying on
+// the v1beta1 defaults for failurePolicy and matchPolicy.
+func v1beta1Fixture() *admissionregistration.MutatingWebhookConfiguration {
+	path := "/mutate-pods"
+	port := int32(443)
+	return &admissionregistration.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admissionregistration.k8s.io/v1beta1",
+			Kind:       "MutatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "mutating-webhook-configuration",
+			Labels: map[string]string{"app": "webhook-server"},
+		},
+		Webhooks: []admissionregistration.Webhook{
+			{
+				Name: "mutatepods.example.com",
+				ClientConfig: admissionregistration.WebhookClientConfig{
+					Service: &admissionregistration.ServiceReference{
+						Namespace: "system",
+						Name:      "webhook-service",
+						Path:      &path,
+						Port:      &port,
+					},
+					CABundle: []byte("fake-ca-bundle"),
+				},
+				Rules: []admissionregistration.RuleWithOperations{
+					{
+						Operations: []admissionregistration.OperationType{admissionregistration.Create},
+						Rule: admissionregistration.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"pods"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMigrateMutatingV1Beta1ToV1(t *testing.T) {
+	migrated, err := MigrateMutatingV1Beta1ToV1(v1beta1Fixture())
+	if err != nil {
+		t.Fatalf("expected migration to succeed, got %v", err)
+	}
+	if migrated.APIVersion != "admissionregistration.k8s.io/v1" {
+		t.Errorf("expected the v1 apiVersion, got %q", migrated.APIVersion)
+	}
+	if migrated.Name != "mutating-webhook-configuration" || migrated.Labels["app"] != "webhook-server" {
+		t.Error("expected the object metadata to be carried over")
+	}
+	if len(migrated.Webhooks) != 1 {
+		t.Fatalf("expected 1 webhook, got %d", len(migrated.Webhooks))
+	}
+	webhook := migrated.Webhooks[0]
+	if webhook.SideEffects == nil || *webhook.SideEffects != admissionregistrationv1.SideEffectClassNoneOnDryRun {
+		t.Errorf("expected sideEffects to default to NoneOnDryRun, got %v", webhook.SideEffects)
+	}
+	if !reflect.DeepEqual(webhook.AdmissionReviewVersions, []string{"v1", "v1beta1"}) {
+		t.Errorf("expected admissionReviewVersions to default to [v1 v1beta1], got %v", webhook.AdmissionReviewVersions)
+	}
+	// the v1beta1 defaults are pinned rather than replaced by the v1 ones
+	if webhook.FailurePolicy == nil || *webhook.FailurePolicy != admissionregistrationv1.Ignore {
+		t.Errorf("expected the v1beta1 default failurePolicy Ignore to be pinned, got %v", webhook.FailurePolicy)
+	}
+	if webhook.MatchPolicy == nil || *webhook.MatchPolicy != admissionregistrationv1.Exact {
+		t.Errorf("expected the v1beta1 default matchPolicy Exact to be pinned, got %v", webhook.MatchPolicy)
+	}
+	if webhook.ClientConfig.Service == nil || webhook.ClientConfig.Service.Name != "webhook-service" {
+		t.Error("expected the client config service to be carried over")
+	}
+	if string(webhook.ClientConfig.CABundle) != "fake-ca-bundle" {
+		t.Error("expected the CABundle to be carried over")
+	}
+}
+
+func TestMigrateValidatingV1Beta1ToV1(t *testing.T) {
+	failurePolicy := admissionregistration.Fail
+	sideEffects := admissionregistration.SideEffectClassNone
+	old := &admissionregistration.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "validating-webhook-configuration"},
+		Webhooks: []admissionregistration.Webhook{
+			{
+				Name:                    "validatepods.example.com",
+				FailurePolicy:           &failurePolicy,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1beta1"},
+			},
+		},
+	}
+	migrated, err := MigrateValidatingV1Beta1ToV1(old)
+	if err != nil {
+		t.Fatalf("expected migration to succeed, got %v", err)
+	}
+	webhook := migrated.Webhooks[0]
+	if webhook.FailurePolicy == nil || *webhook.FailurePolicy != admissionregistrationv1.Fail {
+		t.Errorf("expected the declared failurePolicy to be kept, got %v", webhook.FailurePolicy)
+	}
+	if webhook.SideEffects == nil || *webhook.SideEffects != admissionregistrationv1.SideEffectClassNone {
+		t.Errorf("expected the declared sideEffects to be kept, got %v", webhook.SideEffects)
+	}
+	if !reflect.DeepEqual(webhook.AdmissionReviewVersions, []string{"v1beta1"}) {
+		t.Errorf("expected the declared admissionReviewVersions to be kept, got %v", webhook.AdmissionReviewVersions)
+	}
+}
+
+func TestMigrateRejectsUnknownSideEffects(t *testing.T) {
+	sideEffects := admissionregistration.SideEffectClassUnknown
+	old := v1beta1Fixture()
+	old.Webhooks[0].SideEffects = &sideEffects
+	if _, err := MigrateMutatingV1Beta1ToV1(old); err == nil {
+		t.Error("expected sideEffects Unknown to be rejected, v1 does not allow it")
+	}
+}
+
+func TestMigrateNil(t *testing.T) {
+	if _, err := MigrateMutatingV1Beta1ToV1(nil); err == nil {
+		t.Error("expected migrating a nil mutating configuration to fail")
+	}
+	if _, err := MigrateValidatingV1Beta1ToV1(nil); err == nil {
+		t.Error("expected migrating a nil validating configuration to fail")
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// defaultMetricsPort is the port the webhook server serves metrics on
+	// unless configured otherwise.
+	defaultMetricsPort = 8080
+	// defaultScrapeInterval is the scrape interval applied when none is
+	// configured.
+	defaultScrapeInterval = "30s"
+)
+
+// MonitoringOptions configures the generation of a Prometheus operator
+// ServiceMonitor that scrapes the webhook server metrics.
+type MonitoringOptions struct {
+	// Namespace the ServiceMonitor is created in. Defaults to the namespace
+	// of the webhook service.
+	Namespace string
+	// Labels are set on the ServiceMonitor, so the Prometheus operator's
+	// serviceMonitorSelector can discover it.
+	Labels map[string]string
+	// MetricsPort is the port the webhook server serves metrics on.
+	// Defaults to 8080.
+	MetricsPort int32
+	// ScrapeInterval is the interval at which metrics are scraped, e.g. 1m.
+	// Defaults to 30s.
+	ScrapeInterval string
+}
+
+// metricsPort returns the configured metrics port, defaulted.
+func (m *MonitoringOptions) metricsPort() int32 {
+	if m.MetricsPort > 0 {
+		return m.MetricsPort
+	}
+	return defaultMetricsPort
+}
+
+// scrapeInterval returns the configured scrape interval, defaulted.
+func (m *MonitoringOptions) scrapeInterval() string {
+	if len(m.ScrapeInterval) != 0 {
+		return m.ScrapeInterval
+	}
+	return defaultScrapeInterval
+}
+
+// WithMonitoring enables generating a ServiceMonitor for the webhook server.
+func WithMonitoring(m MonitoringOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.monitoring = &m
+	}
+}
+
+// serviceMonitors creates the ServiceMonitor objects scraping the generated
+// services. It returns nil if monitoring is not configured or the webhook
+// server is not fronted by a service.
+func (o *generatorOptions) serviceMonitors(services []runtime.Object) []runtime.Object {
+	if o.monitoring == nil || o.service == nil {
+		return nil
+	}
+	var monitors []runtime.Object
+	for i := range services {
+		svc, ok := services[i].(*corev1.Service)
+		if !ok {
+			continue
+		}
+		namespace := o.monitoring.Namespace
+		if len(namespace) == 0 {
+			namespace = svc.Namespace
+		}
+		monitors = append(monitors, &monitoringv1.ServiceMonitor{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: monitoringv1.SchemeGroupVersion.String(),
+				Kind:       monitoringv1.ServiceMonitorsKind,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      svc.Name,
+				Namespace: namespace,
+				Labels:    o.monitoring.Labels,
+			},
+			Spec: monitoringv1.ServiceMonitorSpec{
+				Selector: metav1.LabelSelector{
+					MatchLabels: svc.Spec.Selector,
+				},
+				NamespaceSelector: monitoringv1.NamespaceSelector{
+					MatchNames: []string{svc.Namespace},
+				},
+				Endpoints: []monitoringv1.Endpoint{
+					{
+						Port:     "metrics",
+						Interval: o.monitoring.scrapeInterval(),
+					},
+				},
+			},
+		})
+	}
+	return monitors
+}
+
+// addMetricsPorts adds a named metrics port to the generated services, so the
+// ServiceMonitor endpoint has a port to reference. The webhook port gets a
+// name too, since a service with several ports must name all of them.
+func (o *generatorOptions) addMetricsPorts(services []runtime.Object) {
+	port := o.monitoring.metricsPort()
+	for i := range services {
+		svc, ok := services[i].(*corev1.Service)
+		if !ok {
+			continue
+		}
+		for j := range svc.Spec.Ports {
+			if len(svc.Spec.Ports[j].Name) == 0 {
+				svc.Spec.Ports[j].Name = "https"
+			}
+		}
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       "metrics",
+			Port:       port,
+			TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: port},
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateServiceMonitor(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithMonitoring(MonitoringOptions{
+			Labels:         map[string]string{"prometheus": "main"},
+			ScrapeInterval: "30s",
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	var monitor *monitoringv1.ServiceMonitor
+	var service *corev1.Service
+	for _, obj := range objects {
+		switch typed := obj.(type) {
+		case *monitoringv1.ServiceMonitor:
+			monitor = typed
+		case *corev1.Service:
+			service = typed
+		}
+	}
+	if monitor == nil {
+		t.Fatal("expected a ServiceMonitor to be generated")
+	}
+	if monitor.Namespace != "test-system" {
+		t.Errorf("expected the monitor namespace to default to the service namespace, got %q", monitor.Namespace)
+	}
+	if monitor.Labels["prometheus"] != "main" {
+		t.Errorf("unexpected monitor labels: %v", monitor.Labels)
+	}
+	if len(monitor.Spec.Endpoints) != 1 || monitor.Spec.Endpoints[0].Port != "metrics" ||
+		monitor.Spec.Endpoints[0].Interval != "30s" {
+		t.Errorf("unexpected monitor endpoints: %+v", monitor.Spec.Endpoints)
+	}
+	if monitor.Spec.Selector.MatchLabels["app"] != "webhook-server" {
+		t.Errorf("expected the monitor to select the service pods, got %v", monitor.Spec.Selector)
+	}
+
+	// The service grows a named metrics port next to the webhook port.
+	if service == nil {
+		t.Fatal("expected a Service to be generated")
+	}
+	if len(service.Spec.Ports) != 2 {
+		t.Fatalf("expected 2 service ports, got %+v", service.Spec.Ports)
+	}
+	if service.Spec.Ports[0].Name != "https" || service.Spec.Ports[1].Name != "metrics" {
+		t.Errorf("unexpected port names: %+v", service.Spec.Ports)
+	}
+	if service.Spec.Ports[1].Port != defaultMetricsPort {
+		t.Errorf("expected metrics port %d, got %d", defaultMetricsPort, service.Spec.Ports[1].Port)
+	}
+}
+
+func TestServiceMonitorDefaultsAndCustomPort(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithMonitoring(MonitoringOptions{MetricsPort: 9090}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		switch typed := obj.(type) {
+		case *monitoringv1.ServiceMonitor:
+			if typed.Spec.Endpoints[0].Interval != defaultScrapeInterval {
+				t.Errorf("expected the interval to default to %s, got %q", defaultScrapeInterval, typed.Spec.Endpoints[0].Interval)
+			}
+		case *corev1.Service:
+			if typed.Spec.Ports[1].Port != 9090 {
+				t.Errorf("expected the configured metrics port, got %d", typed.Spec.Ports[1].Port)
+			}
+		}
+	}
+}
+
+func TestGenerateNoServiceMonitorWithoutMonitoring(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*monitoringv1.ServiceMonitor); ok {
+			t.Error("expected no ServiceMonitor without monitoring options")
+		}
+	}
+}
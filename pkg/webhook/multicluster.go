@@ -0,0 +1,249 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ClusterConfig identifies one cluster that webhook configuration is
+// generated for, with optional per-cluster overrides of the base options.
+type ClusterConfig struct {
+	// Name is the key of the cluster in the GenerateAll output. If empty, it
+	// is defaulted from Context, then from the kubeconfig file name.
+	Name string
+	// KubeconfigPath is the path of the kubeconfig for this cluster. The
+	// generator does not contact the cluster; the path is carried through so
+	// appliers know where to send the generated objects.
+	KubeconfigPath string
+	// Context is the kubeconfig context to use for this cluster.
+	Context string
+	// Overrides are merged on top of the base options for this cluster.
+	// Registered webhooks are merged by path with the override winning;
+	// other fields replace the base value when set. Build overrides with
+	// NewOverrides, not NewGenerator: the latter applies defaults, which
+	// would override everything.
+	Overrides *generatorOptions
+}
+
+// NewOverrides creates generator options with the given options applied and
+// no defaulting, for use as per-cluster overrides.
+func NewOverrides(opts ...GeneratorOption) *generatorOptions {
+	o := &generatorOptions{
+		registry: map[string]*admissionWebhook{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// MultiClusterGenerator generates webhook configurations for a fleet of
+// clusters from one base configuration, so the clusters stay consistent
+// while still allowing per-cluster differences (e.g. a different target
+// Kubernetes version).
+type MultiClusterGenerator struct {
+	// Base is the configuration shared by all clusters.
+	Base *generatorOptions
+	// Clusters are the clusters to generate for.
+	Clusters []ClusterConfig
+}
+
+// GenerateAll generates the webhook configuration objects for every cluster,
+// keyed by cluster name.
+func (g *MultiClusterGenerator) GenerateAll() (map[string][]runtime.Object, error) {
+	objects := make(map[string][]runtime.Object, len(g.Clusters))
+	for i := range g.Clusters {
+		cluster := &g.Clusters[i]
+		name := cluster.name()
+		if len(name) == 0 {
+			return nil, fmt.Errorf("cluster %d has no name, context or kubeconfig path", i)
+		}
+		if _, found := objects[name]; found {
+			return nil, fmt.Errorf("duplicate cluster name %q", name)
+		}
+		merged := mergeOptions(g.Base, cluster.Overrides)
+		objs, err := merged.Generate()
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q: %v", name, err)
+		}
+		objects[name] = objs
+	}
+	return objects, nil
+}
+
+// name returns the name of the cluster, defaulting from the context and the
+// kubeconfig file name.
+func (c *ClusterConfig) name() string {
+	if len(c.Name) != 0 {
+		return c.Name
+	}
+	if len(c.Context) != 0 {
+		return c.Context
+	}
+	base := filepath.Base(c.KubeconfigPath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// mergeOptions merges the override options on top of the base options into a
+// fresh copy, leaving both inputs untouched. Registered webhooks are merged
+// by path with the override winning; other fields replace the base value
+// when set. Since host and service are mutually exclusive, overriding one
+// clears the other.
+func mergeOptions(base, overrides *generatorOptions) *generatorOptions {
+	merged := *base
+	merged.registry = make(map[string]*admissionWebhook, len(base.registry))
+	for path, wh := range base.registry {
+		merged.registry[path] = wh
+	}
+	if overrides == nil {
+		return &merged
+	}
+	if overrides.port > 0 {
+		merged.port = overrides.port
+	}
+	if len(overrides.certDir) != 0 {
+		merged.certDir = overrides.certDir
+	}
+	if overrides.certReadRetries > 0 {
+		merged.certReadRetries = overrides.certReadRetries
+	}
+	if overrides.certReadInterval > 0 {
+		merged.certReadInterval = overrides.certReadInterval
+	}
+	if len(overrides.mutatingWebhookConfigName) != 0 {
+		merged.mutatingWebhookConfigName = overrides.mutatingWebhookConfigName
+	}
+	if len(overrides.webhookConfigGroup) != 0 {
+		merged.webhookConfigGroup = overrides.webhookConfigGroup
+	}
+	if len(overrides.validatingWebhookConfigName) != 0 {
+		merged.validatingWebhookConfigName = overrides.validatingWebhookConfigName
+	}
+	if overrides.secret != nil {
+		merged.secret = overrides.secret
+	}
+	if overrides.service != nil {
+		merged.service = overrides.service
+		merged.host = nil
+		merged.extraHosts = nil
+	}
+	if overrides.host != nil {
+		merged.host = overrides.host
+		merged.extraHosts = overrides.extraHosts
+		merged.service = nil
+	}
+	if len(overrides.targetK8sVersion) != 0 {
+		merged.targetK8sVersion = overrides.targetK8sVersion
+	}
+	if overrides.defaultReinvocationPolicy != nil {
+		merged.defaultReinvocationPolicy = overrides.defaultReinvocationPolicy
+	}
+	if overrides.requireFailClosed {
+		merged.requireFailClosed = true
+	}
+	if overrides.strict {
+		merged.strict = true
+	}
+	if overrides.labelRouting {
+		merged.labelRouting = true
+	}
+	if overrides.suppressDeleteWarning {
+		merged.suppressDeleteWarning = true
+	}
+	if overrides.metrics != nil {
+		merged.metrics = overrides.metrics
+	}
+	if overrides.monitoring != nil {
+		merged.monitoring = overrides.monitoring
+	}
+	if len(overrides.caBundle) != 0 {
+		merged.caBundle = overrides.caBundle
+	}
+	if overrides.caBundleSecret != nil {
+		merged.caBundleSecret = overrides.caBundleSecret
+	}
+	if overrides.tracing != nil {
+		merged.tracing = overrides.tracing
+	}
+	if len(overrides.allowedClientCIDRs) != 0 {
+		merged.allowedClientCIDRs = overrides.allowedClientCIDRs
+	}
+	if len(overrides.hmacSecret) != 0 {
+		merged.hmacSecret = overrides.hmacSecret
+	}
+	if overrides.excludeOwnNamespace {
+		merged.excludeOwnNamespace = true
+		merged.ownNamespace = overrides.ownNamespace
+	}
+	if overrides.networkPolicy != nil {
+		merged.networkPolicy = overrides.networkPolicy
+	}
+	if overrides.deployment != nil {
+		merged.deployment = overrides.deployment
+	}
+	if overrides.health != nil {
+		merged.health = overrides.health
+	}
+	if overrides.readiness != nil {
+		merged.readiness = overrides.readiness
+	}
+	if len(overrides.podSecurityLevel) != 0 {
+		merged.podSecurityLevel = overrides.podSecurityLevel
+	}
+	if overrides.spiffe != nil {
+		merged.spiffe = overrides.spiffe
+	}
+	if overrides.provisioner != nil {
+		merged.provisioner = overrides.provisioner
+	}
+	if overrides.certManagerIssuer != nil {
+		merged.certManagerIssuer = overrides.certManagerIssuer
+	}
+	if overrides.rbac != nil {
+		merged.rbac = overrides.rbac
+	}
+	if overrides.alerting != nil {
+		merged.alerting = overrides.alerting
+	}
+	if overrides.argocd != nil {
+		merged.argocd = overrides.argocd
+	}
+	if overrides.flux != nil {
+		merged.flux = overrides.flux
+	}
+	if overrides.versionStore != nil {
+		merged.versionStore = overrides.versionStore
+	}
+	for path, wh := range overrides.registry {
+		// Override webhooks have not been through defaulting, so apply the
+		// mutating order prefix here, on a copy to keep the override reusable
+		// across clusters.
+		if wh.Type.matches(webhookTypeMutating) && len(wh.Name) != 0 {
+			cp := *wh
+			cp.Name = fmt.Sprintf("%02d-%s", cp.Order, cp.Name)
+			merged.registry[path] = &cp
+			continue
+		}
+		merged.registry[path] = wh
+	}
+	return &merged
+}
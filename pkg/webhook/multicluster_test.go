@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+func TestGenerateAll(t *testing.T) {
+	base := NewGenerator(
+		WithService(Service{Name: "svc", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	overrides := NewOverrides(
+		WithMutatingWebhookConfigName("staging-mutating-webhook-configuration"),
+	)
+	g := &MultiClusterGenerator{
+		Base: base,
+		Clusters: []ClusterConfig{
+			{Name: "prod", KubeconfigPath: "/etc/kubeconfigs/prod.yaml"},
+			{Context: "staging", Overrides: overrides},
+		},
+	}
+
+	objects, err := g.GenerateAll()
+	if err != nil {
+		t.Fatalf("expected GenerateAll to succeed, got %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected objects for 2 clusters, got %v", objects)
+	}
+	for _, name := range []string{"prod", "staging"} {
+		if len(objects[name]) == 0 {
+			t.Errorf("expected objects for cluster %q", name)
+		}
+	}
+	// the override renames the mutating config only for the staging cluster
+	for name, want := range map[string]string{
+		"prod":    "mutating-webhook-configuration",
+		"staging": "staging-mutating-webhook-configuration",
+	} {
+		var found bool
+		for _, obj := range objects[name] {
+			if cfg, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+				found = true
+				if cfg.Name != want {
+					t.Errorf("cluster %q: expected config name %q, got %q", name, want, cfg.Name)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("cluster %q: expected a MutatingWebhookConfiguration", name)
+		}
+	}
+}
+
+func TestGenerateAllMergesRegistry(t *testing.T) {
+	base := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	overrides := NewOverrides(
+		WithWebhook(testValidatingWebhook("validatepods.example.com", "/validate-pods")),
+	)
+	g := &MultiClusterGenerator{
+		Base:     base,
+		Clusters: []ClusterConfig{{Name: "prod", Overrides: overrides}},
+	}
+	objects, err := g.GenerateAll()
+	if err != nil {
+		t.Fatalf("expected GenerateAll to succeed, got %v", err)
+	}
+	// both the base and the override webhook should be emitted
+	if len(objects["prod"]) != 2 {
+		t.Errorf("expected a mutating and a validating config, got %v", objects["prod"])
+	}
+	// the base registry must not grow from the merge
+	if len(base.registry) != 1 {
+		t.Errorf("expected the base registry to be untouched, got %d entries", len(base.registry))
+	}
+}
+
+func TestGenerateAllDuplicateClusterName(t *testing.T) {
+	base := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	g := &MultiClusterGenerator{
+		Base: base,
+		Clusters: []ClusterConfig{
+			{KubeconfigPath: "/etc/kubeconfigs/prod.yaml"},
+			{Name: "prod"},
+		},
+	}
+	if _, err := g.GenerateAll(); err == nil {
+		t.Error("expected GenerateAll to fail for duplicate cluster names")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// networkPolicyName is the name of the generated NetworkPolicy object.
+const networkPolicyName = "allow-webhook-ingress"
+
+// NetworkPolicyOptions configures the generation of a NetworkPolicy allowing
+// ingress to the webhook port. In clusters with default-deny policies the
+// API server can't reach the webhook service without one, and every matched
+// request times out.
+type NetworkPolicyOptions struct {
+	// SourceCIDR restricts the allowed ingress sources to the given CIDR,
+	// e.g. the API server address range. Mutually exclusive with the
+	// selectors below.
+	SourceCIDR string
+	// SourcePodSelector and SourceNamespaceSelector restrict the allowed
+	// ingress sources to matching pods. If no source is configured, ingress
+	// to the webhook port is allowed from everywhere.
+	SourcePodSelector       *metav1.LabelSelector
+	SourceNamespaceSelector *metav1.LabelSelector
+}
+
+// WithNetworkPolicy enables generating a NetworkPolicy that allows ingress
+// to the webhook port in the service namespace.
+func WithNetworkPolicy(n NetworkPolicyOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.networkPolicy = &n
+	}
+}
+
+// ingressNetworkPolicy creates the NetworkPolicy object allowing ingress to
+// the webhook port for the pods backing the webhook service. It returns nil
+// if network policy generation is not configured or no service is configured.
+func (o *generatorOptions) ingressNetworkPolicy() runtime.Object {
+	if o.networkPolicy == nil || o.service == nil {
+		return nil
+	}
+	port := intstr.FromInt(int(o.port))
+	protocol := corev1.ProtocolTCP
+	rule := networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{
+			{
+				Protocol: &protocol,
+				Port:     &port,
+			},
+		},
+	}
+	// An empty From allows traffic from everywhere on the listed port.
+	if peer := o.networkPolicy.peer(); peer != nil {
+		rule.From = []networkingv1.NetworkPolicyPeer{*peer}
+	}
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+			Kind:       "NetworkPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyName,
+			Namespace: o.service.Namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: o.service.Selectors,
+			},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{rule},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+// peer converts the configured source into a NetworkPolicyPeer, or nil if no
+// source is configured.
+func (n *NetworkPolicyOptions) peer() *networkingv1.NetworkPolicyPeer {
+	if len(n.SourceCIDR) != 0 {
+		return &networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{CIDR: n.SourceCIDR},
+		}
+	}
+	if n.SourcePodSelector == nil && n.SourceNamespaceSelector == nil {
+		return nil
+	}
+	return &networkingv1.NetworkPolicyPeer{
+		PodSelector:       n.SourcePodSelector,
+		NamespaceSelector: n.SourceNamespaceSelector,
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func generatedNetworkPolicy(t *testing.T, opts ...GeneratorOption) *networkingv1.NetworkPolicy {
+	o := NewGenerator(append([]GeneratorOption{
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	}, opts...)...)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if policy, ok := obj.(*networkingv1.NetworkPolicy); ok {
+			return policy
+		}
+	}
+	return nil
+}
+
+func TestGenerateNetworkPolicy(t *testing.T) {
+	policy := generatedNetworkPolicy(t, WithNetworkPolicy(NetworkPolicyOptions{}))
+	if policy == nil {
+		t.Fatal("expected a NetworkPolicy to be generated")
+	}
+	if policy.Namespace != "system" {
+		t.Errorf("expected the policy in the service namespace, got %q", policy.Namespace)
+	}
+	if policy.Spec.PodSelector.MatchLabels["app"] != "webhook-server" {
+		t.Errorf("expected the pod selector to match the service selector, got %+v", policy.Spec.PodSelector)
+	}
+	if len(policy.Spec.Ingress) != 1 {
+		t.Fatalf("expected one ingress rule, got %+v", policy.Spec.Ingress)
+	}
+	rule := policy.Spec.Ingress[0]
+	// no source configured: allow-all on the webhook port
+	if len(rule.From) != 0 {
+		t.Errorf("expected no source restriction by default, got %+v", rule.From)
+	}
+	if len(rule.Ports) != 1 || rule.Ports[0].Port.IntValue() != 443 {
+		t.Errorf("expected the webhook port to be allowed, got %+v", rule.Ports)
+	}
+}
+
+func TestGenerateNetworkPolicySources(t *testing.T) {
+	policy := generatedNetworkPolicy(t, WithNetworkPolicy(NetworkPolicyOptions{SourceCIDR: "10.0.0.0/24"}))
+	if policy == nil {
+		t.Fatal("expected a NetworkPolicy to be generated")
+	}
+	from := policy.Spec.Ingress[0].From
+	if len(from) != 1 || from[0].IPBlock == nil || from[0].IPBlock.CIDR != "10.0.0.0/24" {
+		t.Errorf("expected the CIDR source, got %+v", from)
+	}
+
+	policy = generatedNetworkPolicy(t, WithNetworkPolicy(NetworkPolicyOptions{
+		SourceNamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "control-plane"}},
+	}))
+	from = policy.Spec.Ingress[0].From
+	if len(from) != 1 || from[0].NamespaceSelector == nil || from[0].NamespaceSelector.MatchLabels["role"] != "control-plane" {
+		t.Errorf("expected the namespace selector source, got %+v", from)
+	}
+}
+
+func TestGenerateNetworkPolicyRequiresService(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithNetworkPolicy(NetworkPolicyOptions{}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*networkingv1.NetworkPolicy); ok {
+			t.Error("expected no NetworkPolicy without a service")
+		}
+	}
+}
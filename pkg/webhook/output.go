@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// manifestFilenames maps well-known kinds to short manifest filenames, so
+// the common objects land in predictably named files for kustomize bases.
+var manifestFilenames = map[string]string{
+	"MutatingWebhookConfiguration":   "mutating",
+	"ValidatingWebhookConfiguration": "validating",
+	"Service":                        "service",
+}
+
+// WriteManifestDir generates the webhook manifests and writes each object to
+// its own YAML file in dir, creating the directory if needed. Filenames are
+// derived from the object kind, e.g. mutating.yaml, validating.yaml and
+// service.yaml; if several objects share a kind, the object name is appended
+// to keep the filenames unique. Nil objects are skipped.
+func (o *generatorOptions) WriteManifestDir(dir string) error {
+	objects, err := o.Generate()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, obj := range manifestsByFilename(objects) {
+		content, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestsByFilename assigns each object a stable, kind-based filename.
+func manifestsByFilename(objects []runtime.Object) map[string]runtime.Object {
+	byKind := map[string][]runtime.Object{}
+	for i := range objects {
+		if objects[i] == nil {
+			continue
+		}
+		kind := objects[i].GetObjectKind().GroupVersionKind().Kind
+		byKind[kind] = append(byKind[kind], objects[i])
+	}
+
+	manifests := map[string]runtime.Object{}
+	for kind, objs := range byKind {
+		base, ok := manifestFilenames[kind]
+		if !ok {
+			base = strings.ToLower(kind)
+		}
+		if len(objs) == 1 {
+			manifests[base+".yaml"] = objs[0]
+			continue
+		}
+		for i := range objs {
+			name := base
+			if accessor, ok := objs[i].(metav1.Object); ok {
+				name += "-" + accessor.GetName()
+			}
+			manifests[name+".yaml"] = objs[i]
+		}
+	}
+	return manifests
+}
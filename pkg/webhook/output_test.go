@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteManifestDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-manifests")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	o := NewGenerator(
+		WithService(Service{Name: "svc", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+		WithWebhook(testValidatingWebhook("validatepods.example.com", "/validate-pods")),
+	)
+	target := filepath.Join(dir, "manifests")
+	if err := o.WriteManifestDir(target); err != nil {
+		t.Fatalf("expected WriteManifestDir to succeed, got %v", err)
+	}
+
+	for _, name := range []string{"mutating.yaml", "validating.yaml", "service.yaml"} {
+		content, err := ioutil.ReadFile(filepath.Join(target, name))
+		if err != nil {
+			t.Errorf("expected %s to be written, got %v", name, err)
+			continue
+		}
+		if len(content) == 0 {
+			t.Errorf("expected %s to be non-empty", name)
+		}
+	}
+}
+
+func TestManifestsByFilenameDuplicateKinds(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "svc",
+			Namespace: "system",
+			TypeSelectors: map[string]map[string]string{
+				"mutating":   {"app": "mutating-server"},
+				"validating": {"app": "validating-server"},
+			},
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+		WithWebhook(testValidatingWebhook("validatepods.example.com", "/validate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	manifests := manifestsByFilename(objects)
+	var serviceFiles []string
+	for name := range manifests {
+		if strings.HasPrefix(name, "service-") {
+			serviceFiles = append(serviceFiles, name)
+		}
+	}
+	if len(serviceFiles) != 2 {
+		t.Fatalf("expected 2 service files with name suffixes, got %v", serviceFiles)
+	}
+}
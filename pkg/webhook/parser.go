@@ -0,0 +1,356 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+// ParseWebhookMarkers parses the Go files in the package directory pkgPath
+// and builds webhooks from the webhook markers in their comments.
+//
+// A webhook marker has the form
+//
+//	// +webhook:mutating:resource=pods,group=core,version=v1,failurePolicy=Fail
+//
+// where the second element is the webhook type, i.e. mutating or validating.
+// The supported marker keys are:
+//
+//	group         the API group of the resource ("core" means the empty group)
+//	version       the API version of the resource
+//	resource      the plural resource name, e.g. pods
+//	operations    semicolon-separated operations, e.g. create;update
+//	              (defaults to create;update)
+//	failurePolicy Ignore or Fail
+//	name          the name of the webhook (defaults to <type>.<resource>)
+//	path          the path the webhook is served under
+//	              (defaults to /<type>-<resource>)
+//
+// The kubebuilder-style marker form is supported as well, see
+// parseKubebuilderWebhookTag.
+func ParseWebhookMarkers(pkgPath string) ([]admissionWebhook, error) {
+	var webhooks []admissionWebhook
+	fset := token.NewFileSet()
+
+	files, err := ioutil.ReadDir(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if !isGoFile(f) {
+			continue
+		}
+		whs, err := parseWebhookFile(fset, filepath.Join(pkgPath, f.Name()), nil)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, whs...)
+	}
+	return webhooks, nil
+}
+
+// DiscoverWebhooks walks the directory tree rooted at root and builds
+// webhooks from the webhook markers in all Go files it finds. Webhooks are
+// deduplicated by path: if the same path is declared more than once, the
+// first declaration wins. This allows generating all webhook configs for a
+// multi-controller repo with a single invocation.
+func DiscoverWebhooks(root string) ([]admissionWebhook, error) {
+	var webhooks []admissionWebhook
+	seen := map[string]bool{}
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root,
+		func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !isGoFile(info) {
+				return nil
+			}
+			whs, err := parseWebhookFile(fset, path, nil)
+			if err != nil {
+				return err
+			}
+			for i := range whs {
+				if seen[whs[i].Path] {
+					continue
+				}
+				seen[whs[i].Path] = true
+				webhooks = append(webhooks, whs[i])
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// isGoFile filters out non-Go files and Go test files.
+func isGoFile(f os.FileInfo) bool {
+	name := f.Name()
+	return !f.IsDir() &&
+		!strings.HasPrefix(name, ".") &&
+		!strings.HasSuffix(name, "_test.go") &&
+		strings.HasSuffix(name, ".go")
+}
+
+// parseWebhookFile parses given filename or content src and builds webhooks
+// from the webhook markers in it.
+func parseWebhookFile(fset *token.FileSet, filename string, src interface{}) ([]admissionWebhook, error) {
+	var webhooks []admissionWebhook
+
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// using commentMaps here because it sanitizes the comment text by removing
+	// comment markers, compresses newlines etc.
+	cmap := ast.NewCommentMap(fset, f, f.Comments)
+
+	for _, commentGroup := range cmap.Comments() {
+		for _, comment := range strings.Split(commentGroup.Text(), "\n") {
+			comment := strings.TrimSpace(comment)
+			for whType, prefix := range webhookMarkerPrefixes {
+				if !strings.HasPrefix(comment, prefix) {
+					continue
+				}
+				wh, err := parseWebhookTag(whType, strings.TrimPrefix(comment, prefix))
+				if err != nil {
+					return nil, err
+				}
+				webhooks = append(webhooks, *wh)
+			}
+			if strings.HasPrefix(comment, kubebuilderWebhookMarkerPrefix) {
+				wh, err := parseKubebuilderWebhookTag(strings.TrimPrefix(comment, kubebuilderWebhookMarkerPrefix))
+				if err != nil {
+					return nil, err
+				}
+				webhooks = append(webhooks, *wh)
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+// webhookMarkerPrefixes maps a webhook type to the marker prefix that
+// declares a webhook of that type.
+var webhookMarkerPrefixes = map[webhookType]string{
+	webhookTypeMutating:   "+webhook:mutating:",
+	webhookTypeValidating: "+webhook:validating:",
+}
+
+// kubebuilderWebhookMarkerPrefix is the prefix of the kubebuilder-style
+// webhook marker, which carries the webhook type in its type key instead of
+// in the marker prefix.
+const kubebuilderWebhookMarkerPrefix = "+kubebuilder:webhook:"
+
+// parseKubebuilderWebhookTag parses the key-value part of a kubebuilder-style
+// webhook marker into an admissionWebhook.
+//
+// A kubebuilder webhook marker has the form
+//
+//	// +kubebuilder:webhook:type=mutating,groups=apps,versions=v1,resources=deployments,verbs=create;update
+//
+// The supported marker keys are:
+//
+//	type          mutating or validating
+//	groups        semicolon-separated API groups ("core" means the empty group)
+//	versions      semicolon-separated API versions
+//	resources     semicolon-separated plural resource names
+//	verbs         semicolon-separated operations, e.g. create;update
+//	              (defaults to create;update)
+//	failurePolicy Ignore or Fail
+//	name          the name of the webhook (defaults to <type>.<resource>)
+//	path          the path the webhook is served under
+//	              (defaults to /<type>-<resource>)
+func parseKubebuilderWebhookTag(tag string) (*admissionWebhook, error) {
+	wh := &admissionWebhook{}
+	rule := admissionregistration.Rule{}
+	operations := []admissionregistration.OperationType{
+		admissionregistration.Create,
+		admissionregistration.Update,
+	}
+	var resource string
+	var hasType bool
+
+	for _, elem := range strings.Split(tag, ",") {
+		key, value, err := parseKV(elem)
+		if err != nil {
+			return nil, fmt.Errorf("webhook marker must be key value pairs, got %q", tag)
+		}
+		switch key {
+		case "type":
+			whType, err := parseWebhookType(value)
+			if err != nil {
+				return nil, err
+			}
+			wh.Type = whType
+			hasType = true
+		case "groups":
+			for _, group := range strings.Split(value, ";") {
+				if group == "core" {
+					group = ""
+				}
+				rule.APIGroups = append(rule.APIGroups, group)
+			}
+		case "versions":
+			rule.APIVersions = strings.Split(value, ";")
+		case "resources":
+			rule.Resources = strings.Split(value, ";")
+			resource = rule.Resources[0]
+		case "verbs":
+			operations = nil
+			for _, verb := range strings.Split(value, ";") {
+				operations = append(operations, admissionregistration.OperationType(strings.ToUpper(verb)))
+			}
+		case "failurePolicy":
+			policy, err := ParseFailurePolicy(value)
+			if err != nil {
+				return nil, err
+			}
+			wh.FailurePolicy = policy
+		case "name":
+			wh.Name = value
+		case "path":
+			wh.Path = value
+		default:
+			return nil, fmt.Errorf("unknown webhook marker key %q", key)
+		}
+	}
+
+	if !hasType {
+		return nil, fmt.Errorf("webhook marker should set a type, got %q", tag)
+	}
+	if len(resource) == 0 {
+		return nil, fmt.Errorf("webhook marker should set resources, got %q", tag)
+	}
+	if len(wh.Name) == 0 {
+		wh.Name = fmt.Sprintf("%s.%s", wh.Type, resource)
+	}
+	if len(wh.Path) == 0 {
+		wh.Path = fmt.Sprintf("/%s-%s", wh.Type, resource)
+	}
+	wh.Rules = []admissionregistration.RuleWithOperations{
+		{
+			Operations: operations,
+			Rule:       rule,
+		},
+	}
+	if err := wh.Validate(); err != nil {
+		return nil, err
+	}
+	return wh, nil
+}
+
+// parseWebhookTag parses the key-value part of a webhook marker into an
+// admissionWebhook of the given type.
+func parseWebhookTag(whType webhookType, tag string) (*admissionWebhook, error) {
+	wh := &admissionWebhook{Type: whType}
+	rule := admissionregistration.Rule{}
+	operations := []admissionregistration.OperationType{
+		admissionregistration.Create,
+		admissionregistration.Update,
+	}
+	var resource string
+
+	for _, elem := range strings.Split(tag, ",") {
+		key, value, err := parseKV(elem)
+		if err != nil {
+			return nil, fmt.Errorf("webhook marker must be key value pairs, got %q", tag)
+		}
+		switch key {
+		case "group":
+			if value == "core" {
+				value = ""
+			}
+			rule.APIGroups = []string{value}
+		case "version":
+			rule.APIVersions = []string{value}
+		case "resource":
+			resource = value
+			rule.Resources = []string{value}
+		case "operations":
+			operations = nil
+			for _, op := range strings.Split(value, ";") {
+				operations = append(operations, admissionregistration.OperationType(strings.ToUpper(op)))
+			}
+		case "failurePolicy":
+			policy, err := ParseFailurePolicy(value)
+			if err != nil {
+				return nil, err
+			}
+			wh.FailurePolicy = policy
+		case "name":
+			wh.Name = value
+		case "path":
+			wh.Path = value
+		default:
+			return nil, fmt.Errorf("unknown webhook marker key %q", key)
+		}
+	}
+
+	if len(resource) == 0 {
+		return nil, fmt.Errorf("webhook marker should set a resource, got %q", tag)
+	}
+	if len(wh.Name) == 0 {
+		wh.Name = fmt.Sprintf("%s.%s", whType, resource)
+	}
+	if len(wh.Path) == 0 {
+		wh.Path = fmt.Sprintf("/%s-%s", whType, resource)
+	}
+	wh.Rules = []admissionregistration.RuleWithOperations{
+		{
+			Operations: operations,
+			Rule:       rule,
+		},
+	}
+	if err := wh.Validate(); err != nil {
+		return nil, err
+	}
+	return wh, nil
+}
+
+func parseKV(s string) (key, value string, err error) {
+	kv := strings.Split(s, "=")
+	if len(kv) != 2 {
+		return key, value, fmt.Errorf("invalid key value pair")
+	}
+	key, value = kv[0], kv[1]
+	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		value = value[1 : len(value)-1]
+	}
+	return key, value, nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+func TestParseWebhookFile(t *testing.T) {
+	failurePolicyFail := admissionregistration.Fail
+	tests := []struct {
+		content string
+		exp     []admissionWebhook
+	}{
+		{
+			content: `package foo
+
+	// webhook marker with all keys
+	// +webhook:mutating:resource=pods,group=core,version=v1,operations=create;update,failurePolicy=Fail,name=mutatepods.example.com,path=/mutate-pods
+	type podMutator struct{}`,
+			exp: []admissionWebhook{{
+				Name: "mutatepods.example.com",
+				Type: webhookTypeMutating,
+				Path: "/mutate-pods",
+				Rules: []admissionregistration.RuleWithOperations{{
+					Operations: []admissionregistration.OperationType{
+						admissionregistration.Create,
+						admissionregistration.Update,
+					},
+					Rule: admissionregistration.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   []string{"pods"},
+					},
+				}},
+				FailurePolicy: &failurePolicyFail,
+			}},
+		},
+		{
+			content: `package foo
+
+	// webhook marker relying on defaults
+	// +webhook:validating:resource=deployments,group=apps,version=v1
+	type deploymentValidator struct{}`,
+			exp: []admissionWebhook{{
+				Name: "validating.deployments",
+				Type: webhookTypeValidating,
+				Path: "/validating-deployments",
+				Rules: []admissionregistration.RuleWithOperations{{
+					Operations: []admissionregistration.OperationType{
+						admissionregistration.Create,
+						admissionregistration.Update,
+					},
+					Rule: admissionregistration.Rule{
+						APIGroups:   []string{"apps"},
+						APIVersions: []string{"v1"},
+						Resources:   []string{"deployments"},
+					},
+				}},
+			}},
+		},
+	}
+
+	fset := token.NewFileSet()
+	for _, test := range tests {
+		webhooks, err := parseWebhookFile(fset, "test.go", test.content)
+		if err != nil {
+			t.Errorf("expected parsing to succeed, got %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(webhooks, test.exp) {
+			t.Errorf("expected webhooks:\n%+v\ngot:\n%+v", test.exp, webhooks)
+		}
+	}
+}
+
+func TestParseKubebuilderWebhookMarker(t *testing.T) {
+	failurePolicyIgnore := admissionregistration.Ignore
+	tests := []struct {
+		content string
+		exp     []admissionWebhook
+	}{
+		{
+			content: `package foo
+
+	// kubebuilder marker with all keys
+	// +kubebuilder:webhook:type=mutating,groups=core,versions=v1,resources=pods,verbs=create,failurePolicy=Ignore,name=mutatepods.example.com,path=/mutate-pods
+	type podMutator struct{}`,
+			exp: []admissionWebhook{{
+				Name: "mutatepods.example.com",
+				Type: webhookTypeMutating,
+				Path: "/mutate-pods",
+				Rules: []admissionregistration.RuleWithOperations{{
+					Operations: []admissionregistration.OperationType{
+						admissionregistration.Create,
+					},
+					Rule: admissionregistration.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   []string{"pods"},
+					},
+				}},
+				FailurePolicy: &failurePolicyIgnore,
+			}},
+		},
+		{
+			content: `package foo
+
+	// kubebuilder marker with multiple groups and resources, relying on defaults
+	// +kubebuilder:webhook:type=validating,groups=apps;extensions,versions=v1;v1beta1,resources=deployments;replicasets
+	type deploymentValidator struct{}`,
+			exp: []admissionWebhook{{
+				Name: "validating.deployments",
+				Type: webhookTypeValidating,
+				Path: "/validating-deployments",
+				Rules: []admissionregistration.RuleWithOperations{{
+					Operations: []admissionregistration.OperationType{
+						admissionregistration.Create,
+						admissionregistration.Update,
+					},
+					Rule: admissionregistration.Rule{
+						APIGroups:   []string{"apps", "extensions"},
+						APIVersions: []string{"v1", "v1beta1"},
+						Resources:   []string{"deployments", "replicasets"},
+					},
+				}},
+			}},
+		},
+	}
+
+	fset := token.NewFileSet()
+	for _, test := range tests {
+		webhooks, err := parseWebhookFile(fset, "test.go", test.content)
+		if err != nil {
+			t.Errorf("expected parsing to succeed, got %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(webhooks, test.exp) {
+			t.Errorf("expected webhooks:\n%+v\ngot:\n%+v", test.exp, webhooks)
+		}
+	}
+}
+
+func TestParseKubebuilderWebhookMarkerInvalid(t *testing.T) {
+	contents := []string{
+		// missing type
+		`package foo
+	// +kubebuilder:webhook:groups=apps,versions=v1,resources=deployments
+	type a struct{}`,
+		// unknown type
+		`package foo
+	// +kubebuilder:webhook:type=observing,groups=apps,versions=v1,resources=deployments
+	type b struct{}`,
+		// missing resources
+		`package foo
+	// +kubebuilder:webhook:type=mutating,groups=apps,versions=v1
+	type c struct{}`,
+	}
+
+	fset := token.NewFileSet()
+	for _, content := range contents {
+		if _, err := parseWebhookFile(fset, "test.go", content); err == nil {
+			t.Errorf("expected parsing to fail for content:\n%s", content)
+		}
+	}
+}
+
+func TestDiscoverWebhooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-discover")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatalf("failed to create sub dir: %v", err)
+	}
+	files := map[string]string{
+		"a.go": `package a
+	// +webhook:mutating:resource=pods,group=core,version=v1
+	type a struct{}`,
+		// duplicate path in a nested package should be dropped
+		"sub/b.go": `package b
+	// +webhook:mutating:resource=pods,group=core,version=v1
+	// +webhook:validating:resource=deployments,group=apps,version=v1
+	type b struct{}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	webhooks, err := DiscoverWebhooks(dir)
+	if err != nil {
+		t.Fatalf("expected discovery to succeed, got %v", err)
+	}
+	if len(webhooks) != 2 {
+		t.Fatalf("expected 2 webhooks after deduplication, got %d", len(webhooks))
+	}
+	paths := map[string]bool{}
+	for i := range webhooks {
+		paths[webhooks[i].Path] = true
+	}
+	if !paths["/mutating-pods"] || !paths["/validating-deployments"] {
+		t.Errorf("expected paths /mutating-pods and /validating-deployments, got %v", paths)
+	}
+}
+
+func TestParseWebhookFileInvalidMarker(t *testing.T) {
+	contents := []string{
+		// missing resource
+		`package foo
+	// +webhook:mutating:group=apps,version=v1
+	type a struct{}`,
+		// invalid failure policy
+		`package foo
+	// +webhook:mutating:resource=pods,failurePolicy=Maybe
+	type b struct{}`,
+		// unknown key
+		`package foo
+	// +webhook:validating:resource=pods,color=blue
+	type c struct{}`,
+	}
+
+	fset := token.NewFileSet()
+	for _, content := range contents {
+		if _, err := parseWebhookFile(fset, "test.go", content); err == nil {
+			t.Errorf("expected parsing to fail for content:\n%s", content)
+		}
+	}
+}
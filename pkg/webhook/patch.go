@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/mattbaird/jsonpatch"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// GeneratePatch produces a strategic merge patch that moves the existing
+// object to the desired state, so webhook configurations can be updated in
+// place instead of replaced, avoiding a brief admission outage while
+// requests are in flight. A nil existing object yields a patch creating the
+// desired state from scratch; a nil desired object yields a patch deleting
+// every field of the existing state.
+func GeneratePatch(existing, desired runtime.Object) ([]byte, error) {
+	if existing == nil && desired == nil {
+		return nil, fmt.Errorf("at least one of existing and desired must be set")
+	}
+	dataStruct := desired
+	if dataStruct == nil {
+		dataStruct = existing
+	}
+
+	original, err := marshalOrEmpty(existing)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal existing object: %v", err)
+	}
+	modified, err := marshalOrEmpty(desired)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal desired object: %v", err)
+	}
+
+	patch, err := strategicpatch.CreateTwoWayMergePatch(original, modified, dataStruct)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create merge patch: %v", err)
+	}
+	return patch, nil
+}
+
+// GenerateJSONPatch produces an RFC 6902 JSON Patch that moves the existing
+// object to the desired state, for clients that prefer an explicit operation
+// list over a strategic merge patch. The operations are sorted by path so
+// the output is deterministic. As with GeneratePatch, a nil existing object
+// yields a patch creating the desired state and a nil desired object yields
+// a patch deleting the existing one.
+func GenerateJSONPatch(existing, desired runtime.Object) ([]jsonpatch.JsonPatchOperation, error) {
+	if existing == nil && desired == nil {
+		return nil, fmt.Errorf("at least one of existing and desired must be set")
+	}
+	original, err := marshalOrEmpty(existing)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal existing object: %v", err)
+	}
+	modified, err := marshalOrEmpty(desired)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal desired object: %v", err)
+	}
+	patch, err := jsonpatch.CreatePatch(original, modified)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create JSON patch: %v", err)
+	}
+	sort.Sort(jsonpatch.ByPath(patch))
+	return patch, nil
+}
+
+// marshalOrEmpty marshals the object to JSON, treating nil as the empty
+// object so patches against it create or delete everything.
+func marshalOrEmpty(obj runtime.Object) ([]byte, error) {
+	if obj == nil || reflect.ValueOf(obj).IsNil() {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(obj)
+}
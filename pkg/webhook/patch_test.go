@@ -0,0 +1,183 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mattbaird/jsonpatch"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testWebhookConfiguration(failurePolicy admissionregistration.FailurePolicyType) *admissionregistration.MutatingWebhookConfiguration {
+	return &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mutating-webhook-configuration"},
+		Webhooks: []admissionregistration.Webhook{
+			{
+				Name:          "mutatepods.example.com",
+				FailurePolicy: &failurePolicy,
+			},
+		},
+	}
+}
+
+func TestGeneratePatchUpdate(t *testing.T) {
+	existing := testWebhookConfiguration(admissionregistration.Ignore)
+	desired := testWebhookConfiguration(admissionregistration.Fail)
+
+	patch, err := GeneratePatch(existing, desired)
+	if err != nil {
+		t.Fatalf("expected GeneratePatch to succeed, got %v", err)
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &decoded); err != nil {
+		t.Fatalf("expected the patch to be valid JSON, got %v", err)
+	}
+	if !strings.Contains(string(patch), `"failurePolicy":"Fail"`) {
+		t.Errorf("expected the patch to update the failure policy, got %s", patch)
+	}
+	if _, found := decoded["metadata"]; found {
+		t.Errorf("expected no metadata changes in the patch, got %s", patch)
+	}
+}
+
+func TestGeneratePatchCreate(t *testing.T) {
+	desired := testWebhookConfiguration(admissionregistration.Fail)
+
+	patch, err := GeneratePatch(nil, desired)
+	if err != nil {
+		t.Fatalf("expected GeneratePatch to succeed, got %v", err)
+	}
+	if !strings.Contains(string(patch), `"name":"mutating-webhook-configuration"`) {
+		t.Errorf("expected the patch to create the full object, got %s", patch)
+	}
+}
+
+func TestGeneratePatchDelete(t *testing.T) {
+	existing := testWebhookConfiguration(admissionregistration.Fail)
+
+	patch, err := GeneratePatch(existing, nil)
+	if err != nil {
+		t.Fatalf("expected GeneratePatch to succeed, got %v", err)
+	}
+	if !strings.Contains(string(patch), `"webhooks":null`) {
+		t.Errorf("expected the patch to delete the webhooks, got %s", patch)
+	}
+}
+
+func TestGeneratePatchBothNil(t *testing.T) {
+	if _, err := GeneratePatch(nil, nil); err == nil {
+		t.Error("expected both sides nil to fail")
+	}
+}
+
+// applyJSONPatch applies the add/replace/remove operations to the decoded
+// document. It only supports what the round-trip tests need: object members
+// and array elements addressed without JSON pointer escapes.
+func applyJSONPatch(t *testing.T, doc map[string]interface{}, patch []jsonpatch.JsonPatchOperation) {
+	for _, op := range patch {
+		segments := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")
+		parent := interface{}(doc)
+		for _, segment := range segments[:len(segments)-1] {
+			switch typed := parent.(type) {
+			case map[string]interface{}:
+				parent = typed[segment]
+			case []interface{}:
+				index, err := strconv.Atoi(segment)
+				if err != nil {
+					t.Fatalf("expected an array index in path %q, got %v", op.Path, err)
+				}
+				parent = typed[index]
+			default:
+				t.Fatalf("cannot traverse %q in path %q", segment, op.Path)
+			}
+		}
+		last := segments[len(segments)-1]
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			t.Fatalf("unsupported parent for path %q", op.Path)
+		}
+		switch op.Operation {
+		case "add", "replace":
+			object[last] = op.Value
+		case "remove":
+			delete(object, last)
+		default:
+			t.Fatalf("unsupported operation %q", op.Operation)
+		}
+	}
+}
+
+func TestGenerateJSONPatchRoundTrip(t *testing.T) {
+	existing := testWebhookConfiguration(admissionregistration.Ignore)
+	desired := testWebhookConfiguration(admissionregistration.Fail)
+	desired.Labels = map[string]string{"app": "webhook-server"}
+
+	patch, err := GenerateJSONPatch(existing, desired)
+	if err != nil {
+		t.Fatalf("expected GenerateJSONPatch to succeed, got %v", err)
+	}
+	if len(patch) == 0 {
+		t.Fatal("expected a non-empty patch")
+	}
+
+	existingJSON, err := json.Marshal(existing)
+	if err != nil {
+		t.Fatalf("expected marshaling to succeed, got %v", err)
+	}
+	patched := map[string]interface{}{}
+	if err := json.Unmarshal(existingJSON, &patched); err != nil {
+		t.Fatalf("expected unmarshaling to succeed, got %v", err)
+	}
+	applyJSONPatch(t, patched, patch)
+
+	patchedJSON, err := json.Marshal(patched)
+	if err != nil {
+		t.Fatalf("expected marshaling to succeed, got %v", err)
+	}
+	restored := &admissionregistration.MutatingWebhookConfiguration{}
+	if err := json.Unmarshal(patchedJSON, restored); err != nil {
+		t.Fatalf("expected the patched document to decode, got %v", err)
+	}
+	if !reflect.DeepEqual(restored, desired) {
+		t.Errorf("patched object differs from desired:\nwant %+v\ngot  %+v", desired, restored)
+	}
+}
+
+func TestGenerateJSONPatchNoChanges(t *testing.T) {
+	existing := testWebhookConfiguration(admissionregistration.Fail)
+	desired := testWebhookConfiguration(admissionregistration.Fail)
+
+	patch, err := GenerateJSONPatch(existing, desired)
+	if err != nil {
+		t.Fatalf("expected GenerateJSONPatch to succeed, got %v", err)
+	}
+	if len(patch) != 0 {
+		t.Errorf("expected an empty patch for identical objects, got %v", patch)
+	}
+}
+
+func TestGenerateJSONPatchBothNil(t *testing.T) {
+	if _, err := GenerateJSONPatch(nil, nil); err == nil {
+		t.Error("expected both sides nil to fail")
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// podSecurityEnforceLabel is the namespace label Pod Security Admission
+	// enforces on, holding the enforcement level.
+	podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	// podSecurityEnforceVersionLabel pins the policy version the level is
+	// enforced at.
+	podSecurityEnforceVersionLabel = "pod-security.kubernetes.io/enforce-version"
+)
+
+// WithPodSecurityLevel makes Generate emit the service namespace as a
+// Namespace object labeled with the given Pod Security Admission enforcement
+// level — privileged, baseline or restricted — so clusters enforcing pod
+// security admit the webhook server pods at a declared level.
+func WithPodSecurityLevel(level string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.podSecurityLevel = level
+	}
+}
+
+// validatePodSecurityLevel rejects enforcement levels Pod Security Admission
+// does not know.
+func validatePodSecurityLevel(level string) error {
+	switch level {
+	case "privileged", "baseline", "restricted":
+		return nil
+	}
+	return fmt.Errorf("invalid pod security level %q, should be privileged, baseline or restricted", level)
+}
+
+// webhookNamespace returns the service namespace as a Namespace object
+// labeled for Pod Security Admission, or nil when no level or no service
+// namespace is configured.
+func (o *generatorOptions) webhookNamespace() *corev1.Namespace {
+	if len(o.podSecurityLevel) == 0 || o.service == nil || len(o.service.Namespace) == 0 {
+		return nil
+	}
+	return &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: o.service.Namespace,
+			Labels: map[string]string{
+				podSecurityEnforceLabel:        o.podSecurityLevel,
+				podSecurityEnforceVersionLabel: "latest",
+			},
+		},
+	}
+}
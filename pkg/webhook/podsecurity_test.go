@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGeneratePodSecurityNamespace(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithPodSecurityLevel("restricted"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var namespace *corev1.Namespace
+	for _, obj := range objects {
+		if ns, ok := obj.(*corev1.Namespace); ok {
+			namespace = ns
+		}
+	}
+	if namespace == nil {
+		t.Fatal("expected a Namespace to be generated")
+	}
+	if namespace.Name != "system" {
+		t.Errorf("expected the service namespace, got %q", namespace.Name)
+	}
+	if namespace.Labels[podSecurityEnforceLabel] != "restricted" {
+		t.Errorf("expected the enforce label, got %v", namespace.Labels)
+	}
+	if namespace.Labels[podSecurityEnforceVersionLabel] != "latest" {
+		t.Errorf("expected the enforce-version label, got %v", namespace.Labels)
+	}
+}
+
+func TestPodSecurityLevelValidation(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithPodSecurityLevel("unconfined"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an unknown pod security level to fail validation")
+	}
+}
+
+func TestNoNamespaceWithHost(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithPodSecurityLevel("baseline"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if _, ok := obj.(*corev1.Namespace); ok {
+			t.Fatal("expected no Namespace without a service namespace")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ValidatingAdmissionPolicies creates a ValidatingAdmissionPolicy and
+// ValidatingAdmissionPolicyBinding pair for every enabled validating webhook
+// that declares CEL validations, so those validators can run in-process in
+// the API server instead of round-tripping to the webhook server.
+// ValidatingAdmissionPolicy requires Kubernetes 1.26+. Webhooks without
+// validations are skipped; migrate them by expressing their logic as CEL
+// expressions on the Validations field.
+func (o *generatorOptions) ValidatingAdmissionPolicies() ([]runtime.Object, error) {
+	var paths []string
+	for path, wh := range o.registry {
+		if !wh.Type.matches(webhookTypeValidating) || wh.disabled() || len(wh.Validations) == 0 {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var objects []runtime.Object
+	for _, path := range paths {
+		wh := o.registry[path]
+		if err := wh.Validate(); err != nil {
+			o.metrics.IncValidationError(path)
+			return nil, fmt.Errorf("webhook %q at path %q: %v", wh.Name, path, err)
+		}
+		policy, binding := wh.asPolicy()
+		objects = append(objects, policy, binding)
+	}
+	return objects, nil
+}
+
+// asPolicy converts the webhook's rules, selectors and CEL validations into
+// a ValidatingAdmissionPolicy and a binding enforcing it.
+func (w *admissionWebhook) asPolicy() (*admissionregistrationv1.ValidatingAdmissionPolicy, *admissionregistrationv1.ValidatingAdmissionPolicyBinding) {
+	rules := convertRulesToV1(w.Rules, w.Scope)
+	resourceRules := make([]admissionregistrationv1.NamedRuleWithOperations, len(rules))
+	for i := range rules {
+		resourceRules[i].RuleWithOperations = rules[i]
+	}
+
+	policy := &admissionregistrationv1.ValidatingAdmissionPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingAdmissionPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: w.Name,
+		},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicySpec{
+			MatchConstraints: &admissionregistrationv1.MatchResources{
+				NamespaceSelector: w.NamespaceSelector,
+				ResourceRules:     resourceRules,
+			},
+			Validations:     w.Validations,
+			MatchConditions: w.MatchConditions,
+		},
+	}
+	if w.FailurePolicy != nil {
+		failurePolicy := admissionregistrationv1.FailurePolicyType(*w.FailurePolicy)
+		policy.Spec.FailurePolicy = &failurePolicy
+	}
+
+	binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingAdmissionPolicyBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: w.Name + "-binding",
+		},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName:        w.Name,
+			ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+		},
+	}
+	return policy, binding
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+func TestValidatingAdmissionPolicies(t *testing.T) {
+	failurePolicyFail := admissionregistration.Fail
+	withCEL := testValidatingWebhook("replicalimit.example.com", "/validate-replicas")
+	withCEL.FailurePolicy = &failurePolicyFail
+	withCEL.Validations = []admissionregistrationv1.Validation{
+		{Expression: "object.spec.replicas <= 5", Message: "replicas must be at most 5"},
+	}
+	// webhooks without validations stay on the webhook path
+	withoutCEL := testValidatingWebhook("complex.example.com", "/validate-complex")
+
+	o := NewGenerator(WithHost("localhost"), WithWebhook(withCEL, withoutCEL))
+	objects, err := o.ValidatingAdmissionPolicies()
+	if err != nil {
+		t.Fatalf("expected ValidatingAdmissionPolicies to succeed, got %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("expected a policy and a binding, got %d objects", len(objects))
+	}
+
+	policy, ok := objects[0].(*admissionregistrationv1.ValidatingAdmissionPolicy)
+	if !ok {
+		t.Fatalf("expected a ValidatingAdmissionPolicy, got %T", objects[0])
+	}
+	if policy.Name != withCEL.Name {
+		t.Errorf("expected the policy to be named after the webhook, got %q", policy.Name)
+	}
+	if len(policy.Spec.Validations) != 1 || policy.Spec.Validations[0].Expression != "object.spec.replicas <= 5" {
+		t.Errorf("unexpected validations: %+v", policy.Spec.Validations)
+	}
+	if policy.Spec.MatchConstraints == nil || len(policy.Spec.MatchConstraints.ResourceRules) != 1 {
+		t.Fatalf("expected the webhook rules to become resource rules, got %+v", policy.Spec.MatchConstraints)
+	}
+	rule := policy.Spec.MatchConstraints.ResourceRules[0]
+	if len(rule.Resources) != 1 || rule.Resources[0] != "deployments" {
+		t.Errorf("unexpected resource rule: %+v", rule)
+	}
+	if policy.Spec.FailurePolicy == nil || *policy.Spec.FailurePolicy != admissionregistrationv1.Fail {
+		t.Errorf("expected the failure policy to carry over, got %v", policy.Spec.FailurePolicy)
+	}
+
+	binding, ok := objects[1].(*admissionregistrationv1.ValidatingAdmissionPolicyBinding)
+	if !ok {
+		t.Fatalf("expected a ValidatingAdmissionPolicyBinding, got %T", objects[1])
+	}
+	if binding.Spec.PolicyName != policy.Name {
+		t.Errorf("expected the binding to reference the policy, got %q", binding.Spec.PolicyName)
+	}
+	if len(binding.Spec.ValidationActions) != 1 || binding.Spec.ValidationActions[0] != admissionregistrationv1.Deny {
+		t.Errorf("expected the binding to deny on failure, got %v", binding.Spec.ValidationActions)
+	}
+}
+
+func TestValidationsOnMutatingWebhook(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.Validations = []admissionregistrationv1.Validation{{Expression: "true"}}
+	if err := wh.Validate(); err == nil {
+		t.Error("expected validations on a mutating webhook to fail validation")
+	}
+}
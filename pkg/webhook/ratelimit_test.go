@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+func TestLimitRequestRate(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.RateLimit = &RateLimitConfig{Rate: 1, Burst: 2}
+
+	o := NewGenerator(WithHost("localhost"), WithWebhook(wh))
+	handled := 0
+	handler, err := o.LimitRequestRate("/mutate-pods", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		handled++
+	}))
+	if err != nil {
+		t.Fatalf("expected LimitRequestRate to succeed, got %v", err)
+	}
+
+	// the burst passes through, the request over it is shed
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		last = httptest.NewRecorder()
+		handler.ServeHTTP(last, httptest.NewRequest("POST", "/mutate-pods", nil))
+	}
+	if handled != 2 {
+		t.Errorf("expected the burst of 2 to reach the handler, got %d", handled)
+	}
+	if last.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429 for the shed request, got %d", last.Code)
+	}
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(last.Body.Bytes(), &review); err != nil {
+		t.Fatalf("expected an AdmissionReview response, got %v", err)
+	}
+	// shedding load must not block admission
+	if review.Response == nil || !review.Response.Allowed {
+		t.Errorf("expected an allowing admission response, got %+v", review.Response)
+	}
+}
+
+func TestLimitRequestRateUnlimited(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	o := NewGenerator(WithHost("localhost"), WithWebhook(wh))
+
+	inner := http.NotFoundHandler()
+	handler, err := o.LimitRequestRate("/mutate-pods", inner)
+	if err != nil {
+		t.Fatalf("expected LimitRequestRate to succeed, got %v", err)
+	}
+	// The handlers are HandlerFuncs, so compare the function pointers
+	// instead of the uncomparable interface values.
+	if reflect.ValueOf(handler).Pointer() != reflect.ValueOf(inner).Pointer() {
+		t.Error("expected the handler to be returned unchanged without a rate limit")
+	}
+}
+
+func TestRateLimitValidation(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.RateLimit = &RateLimitConfig{Rate: 0, Burst: 1}
+	if err := wh.Validate(); err == nil {
+		t.Error("expected a non-positive rate to fail validation")
+	}
+	wh.RateLimit = &RateLimitConfig{Rate: 10, Burst: 0}
+	if err := wh.Validate(); err == nil {
+		t.Error("expected a non-positive burst to fail validation")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RBACOptions configures generating the RBAC the webhook server needs: a
+// namespaced Role/RoleBinding for its cert secret, and a ClusterRole with
+// read access to the resources the registered webhooks match, for side
+// lookups during admission. This packages a commonly-forgotten part of
+// webhook deployment alongside the config generation.
+type RBACOptions struct {
+	// ServiceAccount is the service account the webhook server runs as, in
+	// the service namespace. Required.
+	ServiceAccount string
+	// Verbs are the verbs of the derived ClusterRole.
+	// Defaults to get, list and watch.
+	Verbs []string
+}
+
+// WithRBAC enables generating the RBAC objects for the webhook server.
+func WithRBAC(r RBACOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.rbac = &r
+	}
+}
+
+// rbacObjects returns the RBAC objects for the webhook server, or nil when
+// RBAC generation is not configured.
+func (o *generatorOptions) rbacObjects() []runtime.Object {
+	if o.rbac == nil {
+		return nil
+	}
+	subject := rbacv1.Subject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      o.rbac.ServiceAccount,
+		Namespace: o.rbacNamespace(),
+	}
+	objects := []runtime.Object{}
+	if role := o.certSecretRole(); role != nil {
+		objects = append(objects, role, &rbacv1.RoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "RoleBinding",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      role.Name,
+				Namespace: role.Namespace,
+			},
+			Subjects: []rbacv1.Subject{subject},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     role.Name,
+			},
+		})
+	}
+	if clusterRole := o.webhookResourcesClusterRole(); clusterRole != nil {
+		objects = append(objects, clusterRole, &rbacv1.ClusterRoleBinding{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: rbacv1.SchemeGroupVersion.String(),
+				Kind:       "ClusterRoleBinding",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterRole.Name,
+			},
+			Subjects: []rbacv1.Subject{subject},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     clusterRole.Name,
+			},
+		})
+	}
+	return objects
+}
+
+// rbacNamespace returns the namespace the namespaced RBAC objects and the
+// service account live in.
+func (o *generatorOptions) rbacNamespace() string {
+	if o.secret != nil && len(o.secret.Namespace) != 0 {
+		return o.secret.Namespace
+	}
+	if o.service != nil {
+		return o.service.Namespace
+	}
+	return ""
+}
+
+// certSecretRole returns the Role allowing the webhook server to manage its
+// cert secret, or nil when no secret is configured.
+func (o *generatorOptions) certSecretRole() *rbacv1.Role {
+	if o.secret == nil {
+		return nil
+	}
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "Role",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      o.rbac.ServiceAccount + "-cert",
+			Namespace: o.rbacNamespace(),
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{o.secret.Name},
+				Verbs:         []string{"get", "update"},
+			},
+		},
+	}
+}
+
+// webhookResourcesClusterRole returns the ClusterRole with read access to
+// the resources matched by the registered webhooks, or nil when none match
+// anything.
+func (o *generatorOptions) webhookResourcesClusterRole() *rbacv1.ClusterRole {
+	verbs := o.rbac.Verbs
+	if len(verbs) == 0 {
+		verbs = []string{"get", "list", "watch"}
+	}
+	paths := make([]string, 0, len(o.registry))
+	for path := range o.registry {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	rules := []rbacv1.PolicyRule{}
+	for _, path := range paths {
+		wh := o.registry[path]
+		if wh.disabled() {
+			continue
+		}
+		for _, rule := range wh.Rules {
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups: rule.APIGroups,
+				Resources: rule.Resources,
+				Verbs:     verbs,
+			})
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "ClusterRole",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: o.rbac.ServiceAccount + "-resources",
+		},
+		Rules: rules,
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestGenerateRBAC(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithSecret(apitypes.NamespacedName{Namespace: "system", Name: "webhook-cert"}),
+		WithRBAC(RBACOptions{ServiceAccount: "webhook-server"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var role *rbacv1.Role
+	var roleBinding *rbacv1.RoleBinding
+	var clusterRole *rbacv1.ClusterRole
+	var clusterRoleBinding *rbacv1.ClusterRoleBinding
+	for _, obj := range objects {
+		switch typed := obj.(type) {
+		case *rbacv1.Role:
+			role = typed
+		case *rbacv1.RoleBinding:
+			roleBinding = typed
+		case *rbacv1.ClusterRole:
+			clusterRole = typed
+		case *rbacv1.ClusterRoleBinding:
+			clusterRoleBinding = typed
+		}
+	}
+	if role == nil || roleBinding == nil || clusterRole == nil || clusterRoleBinding == nil {
+		t.Fatal("expected the Role, RoleBinding, ClusterRole and ClusterRoleBinding to be generated")
+	}
+	if role.Namespace != "system" {
+		t.Errorf("expected the Role in the secret namespace, got %q", role.Namespace)
+	}
+	rule := role.Rules[0]
+	if len(rule.ResourceNames) != 1 || rule.ResourceNames[0] != "webhook-cert" {
+		t.Errorf("expected the Role to be scoped to the cert secret, got %v", rule.ResourceNames)
+	}
+	if roleBinding.Subjects[0].Name != "webhook-server" || roleBinding.RoleRef.Name != role.Name {
+		t.Errorf("expected the RoleBinding to bind the service account to the Role, got %+v", roleBinding)
+	}
+	// The ClusterRole reads what the registered webhook matches: appsv1
+	// deployments.
+	crRule := clusterRole.Rules[0]
+	if len(crRule.Resources) != 1 || crRule.Resources[0] != "deployments" {
+		t.Errorf("expected the ClusterRole rules to be derived from the webhook rules, got %v", crRule.Resources)
+	}
+	if len(crRule.Verbs) != 3 || crRule.Verbs[0] != "get" {
+		t.Errorf("expected the default read verbs, got %v", crRule.Verbs)
+	}
+	if clusterRoleBinding.RoleRef.Name != clusterRole.Name {
+		t.Errorf("expected the ClusterRoleBinding to reference the ClusterRole, got %+v", clusterRoleBinding.RoleRef)
+	}
+}
+
+func TestRBACServiceAccountValidation(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithRBAC(RBACOptions{}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an empty service account to fail validation")
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// AuditEventFilter selects which audit events to replay. Zero-valued fields
+// match every event.
+type AuditEventFilter struct {
+	// Resource matches the resource of the event, e.g. "pods".
+	Resource string
+	// Namespace matches the namespace of the event.
+	Namespace string
+	// Username matches the requesting user of the event.
+	Username string
+	// Since excludes events received before this time.
+	Since time.Time
+	// Until excludes events received after this time.
+	Until time.Time
+}
+
+// auditEvent holds the subset of a Kubernetes audit event needed to
+// reconstruct an admission request. The audit API types are not vendored,
+// so only the fields we consume are declared.
+type auditEvent struct {
+	AuditID                  types.UID             `json:"auditID"`
+	Stage                    string                `json:"stage"`
+	Verb                     string                `json:"verb"`
+	User                     auditUser             `json:"user"`
+	ObjectRef                *auditObjectRef       `json:"objectRef"`
+	RequestObject            *runtime.RawExtension `json:"requestObject"`
+	RequestReceivedTimestamp metav1.Time           `json:"requestReceivedTimestamp"`
+}
+
+type auditUser struct {
+	Username string   `json:"username"`
+	UID      string   `json:"uid"`
+	Groups   []string `json:"groups"`
+}
+
+type auditObjectRef struct {
+	Resource    string `json:"resource"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	APIGroup    string `json:"apiGroup"`
+	APIVersion  string `json:"apiVersion"`
+	Subresource string `json:"subresource"`
+}
+
+// matches reports whether the event passes the filter.
+func (f AuditEventFilter) matches(ev auditEvent) bool {
+	if ev.ObjectRef == nil {
+		return false
+	}
+	if len(f.Resource) != 0 && ev.ObjectRef.Resource != f.Resource {
+		return false
+	}
+	if len(f.Namespace) != 0 && ev.ObjectRef.Namespace != f.Namespace {
+		return false
+	}
+	if len(f.Username) != 0 && ev.User.Username != f.Username {
+		return false
+	}
+	if !f.Since.IsZero() && ev.RequestReceivedTimestamp.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && ev.RequestReceivedTimestamp.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// operationForVerb maps an audit verb to the admission operation it implies.
+// Verbs that don't go through admission (get, list, watch) return "".
+func operationForVerb(verb string) admissionv1beta1.Operation {
+	switch verb {
+	case "create":
+		return admissionv1beta1.Create
+	case "update", "patch":
+		return admissionv1beta1.Update
+	case "delete", "deletecollection":
+		return admissionv1beta1.Delete
+	}
+	return ""
+}
+
+// ReplayFromAuditLog reads a JSON-format audit log, reconstructs an
+// AdmissionReview request from each event passing the filter, and replays it
+// against the webhook at the given URL. It stops at the first event the
+// webhook fails to answer with HTTP 200.
+func ReplayFromAuditLog(logPath string, webhookURL string, filter AuditEventFilter) error {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return fmt.Errorf("unable to open audit log %v: %v", logPath, err)
+	}
+	defer f.Close() // nolint: errcheck
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		ev := auditEvent{}
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			return fmt.Errorf("unable to decode audit event on line %d: %v", line, err)
+		}
+		// Only ResponseComplete entries carry the full request object.
+		if ev.Stage != "ResponseComplete" || !filter.matches(ev) {
+			continue
+		}
+		op := operationForVerb(ev.Verb)
+		if len(op) == 0 {
+			continue
+		}
+		if err := replayEvent(webhookURL, ev, op); err != nil {
+			return fmt.Errorf("replay of audit event %v failed: %v", ev.AuditID, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// replayEvent sends one reconstructed AdmissionReview to the webhook.
+func replayEvent(webhookURL string, ev auditEvent, op admissionv1beta1.Operation) error {
+	req := &admissionv1beta1.AdmissionRequest{
+		UID: ev.AuditID,
+		Kind: metav1.GroupVersionKind{
+			Group:   ev.ObjectRef.APIGroup,
+			Version: ev.ObjectRef.APIVersion,
+		},
+		Resource: metav1.GroupVersionResource{
+			Group:    ev.ObjectRef.APIGroup,
+			Version:  ev.ObjectRef.APIVersion,
+			Resource: ev.ObjectRef.Resource,
+		},
+		SubResource: ev.ObjectRef.Subresource,
+		Name:        ev.ObjectRef.Name,
+		Namespace:   ev.ObjectRef.Namespace,
+		Operation:   op,
+		UserInfo: authenticationv1.UserInfo{
+			Username: ev.User.Username,
+			UID:      ev.User.UID,
+			Groups:   ev.User.Groups,
+		},
+	}
+	if ev.RequestObject != nil {
+		req.Object = *ev.RequestObject
+	}
+	review := admissionv1beta1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1beta1",
+			Kind:       "AdmissionReview",
+		},
+		Request: req,
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook responded with status %v", resp.Status)
+	}
+	return nil
+}
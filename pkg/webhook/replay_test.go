@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+const testAuditLog = `{"auditID":"uid-1","stage":"ResponseComplete","verb":"create","user":{"username":"jane"},"objectRef":{"resource":"pods","namespace":"default","name":"foo","apiVersion":"v1"},"requestReceivedTimestamp":"2018-11-01T10:00:00Z"}
+{"auditID":"uid-2","stage":"ResponseComplete","verb":"get","user":{"username":"jane"},"objectRef":{"resource":"pods","namespace":"default","name":"foo","apiVersion":"v1"},"requestReceivedTimestamp":"2018-11-01T10:00:01Z"}
+{"auditID":"uid-3","stage":"RequestReceived","verb":"create","user":{"username":"jane"},"objectRef":{"resource":"pods","namespace":"default","name":"bar","apiVersion":"v1"},"requestReceivedTimestamp":"2018-11-01T10:00:02Z"}
+{"auditID":"uid-4","stage":"ResponseComplete","verb":"update","user":{"username":"bob"},"objectRef":{"resource":"deployments","namespace":"kube-system","name":"baz","apiGroup":"apps","apiVersion":"v1"},"requestReceivedTimestamp":"2018-11-01T10:00:03Z"}
+`
+
+func writeTestAuditLog(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "replay-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) }) // nolint: errcheck
+	logPath := filepath.Join(dir, "audit.log")
+	if err := ioutil.WriteFile(logPath, []byte(testAuditLog), 0600); err != nil {
+		t.Fatalf("failed to write audit log: %v", err)
+	}
+	return logPath
+}
+
+func TestReplayFromAuditLog(t *testing.T) {
+	var received []admissionv1beta1.AdmissionReview
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := admissionv1beta1.AdmissionReview{}
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			t.Errorf("failed to decode replayed review: %v", err)
+		}
+		received = append(received, review)
+	}))
+	defer server.Close()
+
+	err := ReplayFromAuditLog(writeTestAuditLog(t), server.URL, AuditEventFilter{})
+	if err != nil {
+		t.Fatalf("expected the replay to succeed, got %v", err)
+	}
+	// uid-2 is a get and uid-3 never completed; only uid-1 and uid-4 replay.
+	if len(received) != 2 {
+		t.Fatalf("expected 2 replayed events, got %d", len(received))
+	}
+	if received[0].Request.UID != "uid-1" || received[0].Request.Operation != admissionv1beta1.Create {
+		t.Errorf("unexpected first replayed request: %+v", received[0].Request)
+	}
+	if received[1].Request.UID != "uid-4" || received[1].Request.Operation != admissionv1beta1.Update {
+		t.Errorf("unexpected second replayed request: %+v", received[1].Request)
+	}
+}
+
+func TestReplayFromAuditLogFiltered(t *testing.T) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+	}))
+	defer server.Close()
+
+	logPath := writeTestAuditLog(t)
+	tests := []struct {
+		filter   AuditEventFilter
+		expected int
+	}{
+		{AuditEventFilter{Resource: "pods"}, 1},
+		{AuditEventFilter{Namespace: "kube-system"}, 1},
+		{AuditEventFilter{Username: "nobody"}, 0},
+	}
+	for _, test := range tests {
+		count = 0
+		if err := ReplayFromAuditLog(logPath, server.URL, test.filter); err != nil {
+			t.Fatalf("expected the replay to succeed, got %v", err)
+		}
+		if count != test.expected {
+			t.Errorf("filter %+v: expected %d replayed events, got %d", test.filter, test.expected, count)
+		}
+	}
+}
+
+func TestReplayFromAuditLogWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := ReplayFromAuditLog(writeTestAuditLog(t), server.URL, AuditEventFilter{}); err == nil {
+		t.Error("expected a webhook error to fail the replay")
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkpointVersionFormat is the timestamp layout used as the version key of
+// a checkpoint. It sorts lexicographically in chronological order and is
+// precise enough that two checkpoints in one process don't collide.
+const checkpointVersionFormat = "20060102-150405.000000000"
+
+// ConfigVersionStore stores serialized webhook configuration versions, so a
+// bad rollout can be reverted without re-running the generator.
+type ConfigVersionStore interface {
+	// Save stores the serialized configuration under the given version.
+	Save(ctx context.Context, c client.Client, version string, data []byte) error
+	// Load returns the serialized configuration stored under the given
+	// version.
+	Load(ctx context.Context, c client.Client, version string) ([]byte, error)
+	// Versions returns the stored versions, oldest first.
+	Versions(ctx context.Context, c client.Client) ([]string, error)
+}
+
+// ConfigMapVersionStore implements ConfigVersionStore on a ConfigMap, with
+// one data key per version. The ConfigMap is created on first save.
+type ConfigMapVersionStore struct {
+	// Namespace and Name locate the ConfigMap.
+	Namespace string
+	Name      string
+	// MaxVersions caps the stored history; the oldest versions are pruned on
+	// save. Zero means unlimited.
+	MaxVersions int
+}
+
+// Save implements ConfigVersionStore.
+func (s *ConfigMapVersionStore) Save(ctx context.Context, c client.Client, version string, data []byte) error {
+	cm, err := s.configMap(ctx, c)
+	create := false
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		create = true
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: s.Namespace, Name: s.Name},
+		}
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[version] = string(data)
+	if s.MaxVersions > 0 {
+		versions := sortedKeys(cm.Data)
+		for len(versions) > s.MaxVersions {
+			delete(cm.Data, versions[0])
+			versions = versions[1:]
+		}
+	}
+	if create {
+		return c.Create(ctx, cm)
+	}
+	return c.Update(ctx, cm)
+}
+
+// Load implements ConfigVersionStore.
+func (s *ConfigMapVersionStore) Load(ctx context.Context, c client.Client, version string) ([]byte, error) {
+	cm, err := s.configMap(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	data, found := cm.Data[version]
+	if !found {
+		return nil, fmt.Errorf("version %q not found in ConfigMap %s/%s, stored versions are %v", version, s.Namespace, s.Name, sortedKeys(cm.Data))
+	}
+	return []byte(data), nil
+}
+
+// Versions implements ConfigVersionStore.
+func (s *ConfigMapVersionStore) Versions(ctx context.Context, c client.Client) ([]string, error) {
+	cm, err := s.configMap(ctx, c)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sortedKeys(cm.Data), nil
+}
+
+// configMap reads the backing ConfigMap.
+func (s *ConfigMapVersionStore) configMap(ctx context.Context, c client.Client) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: s.Namespace, Name: s.Name}
+	if err := c.Get(ctx, key, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// sortedKeys returns the map keys in ascending order. Since checkpoint
+// versions are sortable timestamps, this is oldest first.
+func sortedKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WithVersionStore sets the store Checkpoint and Rollback keep configuration
+// versions in.
+func WithVersionStore(store ConfigVersionStore) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.versionStore = store
+	}
+}
+
+// WebhookConfigVersion is one stored configuration version together with
+// its audit metadata, for compliance trails of when the configuration
+// changed and who changed it.
+type WebhookConfigVersion struct {
+	// Version is the store key the entry is kept under.
+	Version string `json:"version"`
+	// Timestamp is when the version was checkpointed.
+	Timestamp time.Time `json:"timestamp"`
+	// Author identifies who made the change.
+	Author string `json:"author,omitempty"`
+	// Description is a free-form note about the change.
+	Description string `json:"description,omitempty"`
+	// Config holds the configuration objects of this version.
+	Config []runtime.Object `json:"-"`
+}
+
+// storedConfigVersion is the JSON shape kept in the store. The objects are
+// stored as raw JSON so decoding is deferred until they are needed.
+type storedConfigVersion struct {
+	Timestamp   time.Time         `json:"timestamp"`
+	Author      string            `json:"author,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Config      []json.RawMessage `json:"config"`
+}
+
+// Checkpoint serializes the given objects (typically the Generate output as
+// it was applied) and stores them in the configured version store under a
+// timestamp version, so a later Rollback can revert to them.
+func (o *generatorOptions) Checkpoint(ctx context.Context, c client.Client, objects []runtime.Object) error {
+	return o.CheckpointWithMetadata(ctx, c, objects, "", "")
+}
+
+// CheckpointWithMetadata is Checkpoint with the author and description
+// recorded on the stored version for audit trails.
+func (o *generatorOptions) CheckpointWithMetadata(ctx context.Context, c client.Client, objects []runtime.Object, author, description string) error {
+	if o.versionStore == nil {
+		return errors.New("no version store is configured, use WithVersionStore")
+	}
+	stored := storedConfigVersion{
+		Timestamp:   time.Now().UTC(),
+		Author:      author,
+		Description: description,
+	}
+	for i := range objects {
+		if objects[i] == nil {
+			continue
+		}
+		content, err := json.Marshal(objects[i])
+		if err != nil {
+			return err
+		}
+		stored.Config = append(stored.Config, content)
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	version := stored.Timestamp.Format(checkpointVersionFormat)
+	return o.versionStore.Save(ctx, c, version, data)
+}
+
+// ListVersions returns the stored configuration versions with their audit
+// metadata, oldest first.
+func (o *generatorOptions) ListVersions(ctx context.Context, c client.Client) ([]WebhookConfigVersion, error) {
+	if o.versionStore == nil {
+		return nil, errors.New("no version store is configured, use WithVersionStore")
+	}
+	keys, err := o.versionStore.Versions(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]WebhookConfigVersion, 0, len(keys))
+	for _, key := range keys {
+		version, err := o.GetVersion(ctx, c, key)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+	return versions, nil
+}
+
+// GetVersion returns the stored configuration version with its audit
+// metadata and decoded objects.
+func (o *generatorOptions) GetVersion(ctx context.Context, c client.Client, version string) (*WebhookConfigVersion, error) {
+	if o.versionStore == nil {
+		return nil, errors.New("no version store is configured, use WithVersionStore")
+	}
+	data, err := o.versionStore.Load(ctx, c, version)
+	if err != nil {
+		return nil, err
+	}
+	var stored storedConfigVersion
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode version %q: %v", version, err)
+	}
+	result := &WebhookConfigVersion{
+		Version:     version,
+		Timestamp:   stored.Timestamp,
+		Author:      stored.Author,
+		Description: stored.Description,
+	}
+	for _, raw := range stored.Config {
+		obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(raw, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode an object of version %q: %v", version, err)
+		}
+		result.Config = append(result.Config, obj)
+	}
+	return result, nil
+}
+
+// Rollback loads the configuration stored under the given version and
+// applies it, reverting the cluster to that checkpoint. Only kinds
+// registered in the client-go scheme can be restored; the admission
+// configurations, services and secrets the generator emits all are.
+func (o *generatorOptions) Rollback(ctx context.Context, c client.Client, version string) error {
+	stored, err := o.GetVersion(ctx, c, version)
+	if err != nil {
+		return err
+	}
+	for _, obj := range stored.Config {
+		if err := createOrUpdate(ctx, c, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createOrUpdate creates the object, or updates it if it already exists.
+func createOrUpdate(ctx context.Context, c client.Client, obj runtime.Object) error {
+	err := c.Create(ctx, obj)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	existing := obj.DeepCopyObject()
+	key := client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	if err := c.Get(ctx, key, existing); err != nil {
+		return err
+	}
+	existingAccessor, err := meta.Accessor(existing)
+	if err != nil {
+		return err
+	}
+	accessor.SetResourceVersion(existingAccessor.GetResourceVersion())
+	return c.Update(ctx, obj)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func checkpointGenerator(store ConfigVersionStore) *generatorOptions {
+	return NewGenerator(
+		WithHost("localhost"),
+		WithVersionStore(store),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+}
+
+func TestCheckpointAndRollback(t *testing.T) {
+	store := &ConfigMapVersionStore{Namespace: "system", Name: "webhook-config-history"}
+	c := fake.NewFakeClient()
+	o := checkpointGenerator(store)
+
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	if err := o.Checkpoint(context.TODO(), c, objects); err != nil {
+		t.Fatalf("expected Checkpoint to succeed, got %v", err)
+	}
+	versions, err := store.Versions(context.TODO(), c)
+	if err != nil {
+		t.Fatalf("expected listing versions to succeed, got %v", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("expected 1 stored version, got %d", len(versions))
+	}
+
+	if err := o.Rollback(context.TODO(), c, versions[0]); err != nil {
+		t.Fatalf("expected Rollback to succeed, got %v", err)
+	}
+	cfg := &admissionregistration.MutatingWebhookConfiguration{}
+	key := client.ObjectKey{Name: "mutating-webhook-configuration"}
+	if err := c.Get(context.TODO(), key, cfg); err != nil {
+		t.Fatalf("expected the rolled back configuration to exist, got %v", err)
+	}
+	if len(cfg.Webhooks) != 1 || cfg.Webhooks[0].Name != "00-mutatepods.example.com" {
+		t.Errorf("expected the checkpointed webhook to be restored, got %v", cfg.Webhooks)
+	}
+
+	// rolling back over an existing object updates it in place
+	if err := o.Rollback(context.TODO(), c, versions[0]); err != nil {
+		t.Fatalf("expected a repeated Rollback to succeed, got %v", err)
+	}
+}
+
+func TestRollbackUnknownVersion(t *testing.T) {
+	store := &ConfigMapVersionStore{Namespace: "system", Name: "webhook-config-history"}
+	c := fake.NewFakeClient()
+	o := checkpointGenerator(store)
+
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	if err := o.Checkpoint(context.TODO(), c, objects); err != nil {
+		t.Fatalf("expected Checkpoint to succeed, got %v", err)
+	}
+	if err := o.Rollback(context.TODO(), c, "no-such-version"); err == nil {
+		t.Error("expected rolling back to an unknown version to fail")
+	}
+}
+
+func TestCheckpointWithoutStore(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Checkpoint(context.TODO(), fake.NewFakeClient(), nil); err == nil {
+		t.Error("expected Checkpoint without a configured store to fail")
+	}
+	if err := o.Rollback(context.TODO(), fake.NewFakeClient(), "any"); err == nil {
+		t.Error("expected Rollback without a configured store to fail")
+	}
+}
+
+func TestConfigMapVersionStorePruning(t *testing.T) {
+	store := &ConfigMapVersionStore{Namespace: "system", Name: "webhook-config-history", MaxVersions: 2}
+	c := fake.NewFakeClient()
+
+	for _, version := range []string{"01", "02", "03"} {
+		if err := store.Save(context.TODO(), c, version, []byte("data-"+version)); err != nil {
+			t.Fatalf("expected saving version %q to succeed, got %v", version, err)
+		}
+	}
+	versions, err := store.Versions(context.TODO(), c)
+	if err != nil {
+		t.Fatalf("expected listing versions to succeed, got %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "02" || versions[1] != "03" {
+		t.Errorf("expected the oldest version to be pruned, got %v", versions)
+	}
+	if _, err := store.Load(context.TODO(), c, "01"); err == nil {
+		t.Error("expected the pruned version to be gone")
+	}
+	if data, err := store.Load(context.TODO(), c, "03"); err != nil || string(data) != "data-03" {
+		t.Errorf("expected the newest version to be intact, got %q, %v", data, err)
+	}
+}
+
+func TestConfigVersionHistory(t *testing.T) {
+	store := &ConfigMapVersionStore{Namespace: "system", Name: "webhook-config-history"}
+	c := fake.NewFakeClient()
+	o := checkpointGenerator(store)
+
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	if err := o.CheckpointWithMetadata(context.TODO(), c, objects, "alex@example.com", "enable pod mutation"); err != nil {
+		t.Fatalf("expected Checkpoint to succeed, got %v", err)
+	}
+
+	versions, err := o.ListVersions(context.TODO(), c)
+	if err != nil {
+		t.Fatalf("expected ListVersions to succeed, got %v", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("expected 1 version, got %d", len(versions))
+	}
+	version := versions[0]
+	if version.Author != "alex@example.com" || version.Description != "enable pod mutation" {
+		t.Errorf("expected the audit metadata to be stored, got author %q description %q", version.Author, version.Description)
+	}
+	if version.Timestamp.IsZero() {
+		t.Error("expected the timestamp to be recorded")
+	}
+	if len(version.Config) != len(objects) {
+		t.Errorf("expected %d stored objects, got %d", len(objects), len(version.Config))
+	}
+
+	got, err := o.GetVersion(context.TODO(), c, version.Version)
+	if err != nil {
+		t.Fatalf("expected GetVersion to succeed, got %v", err)
+	}
+	if got.Author != version.Author || !got.Timestamp.Equal(version.Timestamp) {
+		t.Error("expected GetVersion to return the listed version")
+	}
+}
+
+func TestGetVersionUnknown(t *testing.T) {
+	store := &ConfigMapVersionStore{Namespace: "system", Name: "webhook-config-history"}
+	c := fake.NewFakeClient()
+	o := checkpointGenerator(store)
+	if _, err := o.GetVersion(context.TODO(), c, "no-such-version"); err == nil {
+		t.Error("expected getting an unknown version to fail")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certSecretTLSCertKey is the secret key the serving certificate is stored
+// under by the cert provisioners.
+const certSecretTLSCertKey = "tls.crt"
+
+// WatchAndRotateCABundle watches the cert secret and patches the CABundle of
+// the named webhook configuration whenever the secret's tls.crt key changes,
+// so a rotated certificate doesn't leave the configuration pointing at a
+// stale CA. It blocks until the context is canceled or patching fails.
+func WatchAndRotateCABundle(ctx context.Context, c client.Client, secretNamespace, secretName, webhookConfigName string, isMutating bool) error {
+	return WatchAndRotateCABundleWithInterval(ctx, c, secretNamespace, secretName, webhookConfigName, isMutating, defaultSecretResyncPeriod)
+}
+
+// WatchAndRotateCABundleWithInterval is like WatchAndRotateCABundle with a
+// caller-chosen re-check interval instead of the default.
+func WatchAndRotateCABundleWithInterval(ctx context.Context, c client.Client, secretNamespace, secretName, webhookConfigName string, isMutating bool, interval time.Duration) error {
+	var lastCert []byte
+	sync := func() error {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: secretNamespace, Name: secretName}
+		if err := c.Get(ctx, key, secret); err != nil {
+			// The secret may not have been provisioned yet; keep waiting.
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		cert := secret.Data[certSecretTLSCertKey]
+		if len(cert) == 0 || bytes.Equal(cert, lastCert) {
+			return nil
+		}
+		if err := rotateCABundle(ctx, c, webhookConfigName, isMutating, cert); err != nil {
+			return err
+		}
+		lastCert = cert
+		return nil
+	}
+	if err := sync(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := sync(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rotateCABundle sets the CABundle on every webhook entry of the named
+// configuration.
+func rotateCABundle(ctx context.Context, c client.Client, webhookConfigName string, isMutating bool, caBundle []byte) error {
+	key := client.ObjectKey{Name: webhookConfigName}
+	if isMutating {
+		cfg := &admissionregistration.MutatingWebhookConfiguration{}
+		if err := c.Get(ctx, key, cfg); err != nil {
+			return fmt.Errorf("failed to get webhook configuration %q: %v", webhookConfigName, err)
+		}
+		for i := range cfg.Webhooks {
+			cfg.Webhooks[i].ClientConfig.CABundle = caBundle
+		}
+		return c.Update(ctx, cfg)
+	}
+	cfg := &admissionregistration.ValidatingWebhookConfiguration{}
+	if err := c.Get(ctx, key, cfg); err != nil {
+		return fmt.Errorf("failed to get webhook configuration %q: %v", webhookConfigName, err)
+	}
+	for i := range cfg.Webhooks {
+		cfg.Webhooks[i].ClientConfig.CABundle = caBundle
+	}
+	return c.Update(ctx, cfg)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func rotationFixtures(cert []byte) (*corev1.Secret, *admissionregistration.MutatingWebhookConfiguration) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "system", Name: "webhook-cert"},
+		Data:       map[string][]byte{certSecretTLSCertKey: cert},
+	}
+	cfg := &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mutating-webhook-configuration"},
+		Webhooks: []admissionregistration.Webhook{
+			{Name: "mutatepods.example.com"},
+			{Name: "mutatedeployments.example.com"},
+		},
+	}
+	return secret, cfg
+}
+
+func TestRotateCABundle(t *testing.T) {
+	secret, cfg := rotationFixtures([]byte("old-cert"))
+	c := fake.NewFakeClient(secret, cfg)
+	if err := rotateCABundle(context.TODO(), c, cfg.Name, true, []byte("new-cert")); err != nil {
+		t.Fatalf("expected rotateCABundle to succeed, got %v", err)
+	}
+	updated := &admissionregistration.MutatingWebhookConfiguration{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: cfg.Name}, updated); err != nil {
+		t.Fatalf("failed to get the configuration: %v", err)
+	}
+	for _, wh := range updated.Webhooks {
+		if string(wh.ClientConfig.CABundle) != "new-cert" {
+			t.Errorf("expected every entry to carry the new bundle, got %q", wh.ClientConfig.CABundle)
+		}
+	}
+}
+
+func TestWatchAndRotateCABundle(t *testing.T) {
+	secret, cfg := rotationFixtures([]byte("rotated-cert"))
+	c := fake.NewFakeClient(secret, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchAndRotateCABundleWithInterval(ctx, c, "system", "webhook-cert", cfg.Name, true, 10*time.Millisecond)
+	}()
+
+	// The initial sync picks up the secret content; poll until the
+	// configuration reflects it.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		updated := &admissionregistration.MutatingWebhookConfiguration{}
+		if err := c.Get(context.TODO(), client.ObjectKey{Name: cfg.Name}, updated); err != nil {
+			t.Fatalf("failed to get the configuration: %v", err)
+		}
+		if string(updated.Webhooks[0].ClientConfig.CABundle) == "rotated-cert" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the CABundle to be rotated from the secret")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the watch to stop cleanly, got %v", err)
+	}
+}
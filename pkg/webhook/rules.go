@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/markbates/inflect"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// ExpandAllExcept enumerates the resources served in the given group/version
+// and returns them minus the given exclusions, suitable for use as the
+// Resources of an admission rule. Admission rules can't express "all resources
+// except X" directly, so this produces the explicit list instead.
+// Subresources (e.g. pods/status) are not included.
+func ExpandAllExcept(disc discovery.DiscoveryInterface, group, version string, except []string) ([]string, error) {
+	gv := schema.GroupVersion{Group: group, Version: version}
+	resourceList, err := disc.ServerResourcesForGroupVersion(gv.String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover resources in %v: %v", gv, err)
+	}
+
+	excluded := map[string]bool{}
+	for _, name := range except {
+		excluded[name] = true
+	}
+
+	var resources []string
+	seen := map[string]bool{}
+	for _, resource := range resourceList.APIResources {
+		// Skip subresources; admission rules target the parent resource.
+		if strings.Contains(resource.Name, "/") {
+			continue
+		}
+		if excluded[resource.Name] || seen[resource.Name] {
+			continue
+		}
+		seen[resource.Name] = true
+		resources = append(resources, resource.Name)
+	}
+	sort.Strings(resources)
+	return resources, nil
+}
+
+// RuleForGVK builds a RuleWithOperations for the given GroupVersionKind,
+// deriving the resource plural by pluralizing the kind. It removes a frequent
+// source of singular/plural mistakes when registering webhooks by hand.
+func RuleForGVK(gvk schema.GroupVersionKind, ops []admissionregistration.OperationType, scope admissionregistration.ScopeType) admissionregistration.RuleWithOperations {
+	resource := strings.ToLower(inflect.Pluralize(gvk.Kind))
+	return ruleFor(gvk, resource, ops, scope)
+}
+
+// RuleForGVKWithMapper is like RuleForGVK, but derives the resource plural
+// from the given RESTMapper, for kinds whose plural is registered rather
+// than derivable by pluralization.
+func RuleForGVKWithMapper(mapper meta.RESTMapper, gvk schema.GroupVersionKind, ops []admissionregistration.OperationType, scope admissionregistration.ScopeType) (admissionregistration.RuleWithOperations, error) {
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return admissionregistration.RuleWithOperations{}, err
+	}
+	return ruleFor(gvk, mapping.Resource.Resource, ops, scope), nil
+}
+
+// RuleWithSubresource copies the rule, pointing every resource entry at the
+// given subresource (e.g. pods becomes pods/status). Resources that already
+// name a subresource are left alone.
+func RuleWithSubresource(rule admissionregistration.RuleWithOperations, subresource string) admissionregistration.RuleWithOperations {
+	cp := admissionregistration.RuleWithOperations{}
+	rule.DeepCopyInto(&cp)
+	for i, resource := range cp.Resources {
+		if strings.Contains(resource, "/") {
+			continue
+		}
+		cp.Resources[i] = resource + "/" + subresource
+	}
+	return cp
+}
+
+// ruleFor assembles the RuleWithOperations from its derived pieces.
+func ruleFor(gvk schema.GroupVersionKind, resource string, ops []admissionregistration.OperationType, scope admissionregistration.ScopeType) admissionregistration.RuleWithOperations {
+	rule := admissionregistration.RuleWithOperations{
+		Operations: ops,
+		Rule: admissionregistration.Rule{
+			APIGroups:   []string{gvk.Group},
+			APIVersions: []string{gvk.Version},
+			Resources:   []string{resource},
+		},
+	}
+	if len(scope) != 0 {
+		rule.Rule.Scope = &scope
+	}
+	return rule
+}
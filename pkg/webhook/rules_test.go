@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func fakeDiscovery(resourceLists ...*metav1.APIResourceList) *fakediscovery.FakeDiscovery {
+	fake := &clienttesting.Fake{Resources: resourceLists}
+	return &fakediscovery.FakeDiscovery{Fake: fake}
+}
+
+func TestExpandAllExcept(t *testing.T) {
+	disc := fakeDiscovery(&metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods"},
+			{Name: "pods/status"},
+			{Name: "services"},
+			{Name: "configmaps"},
+			{Name: "events"},
+		},
+	})
+
+	resources, err := ExpandAllExcept(disc, "", "v1", []string{"events"})
+	if err != nil {
+		t.Fatalf("expected the expansion to succeed, got %v", err)
+	}
+	expected := []string{"configmaps", "pods", "services"}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("expected resources %v, got %v", expected, resources)
+	}
+}
+
+func TestExpandAllExceptUnknownGroupVersion(t *testing.T) {
+	disc := fakeDiscovery()
+	if _, err := ExpandAllExcept(disc, "apps", "v1", nil); err == nil {
+		t.Error("expected an unknown group/version to fail")
+	}
+}
+
+func TestRuleForGVK(t *testing.T) {
+	tests := []struct {
+		gvk      schema.GroupVersionKind
+		resource string
+	}{
+		{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "deployments"},
+		{schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"}, "podsecuritypolicies"},
+		{schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, "pods"},
+	}
+	for _, test := range tests {
+		rule := RuleForGVK(test.gvk, []admissionregistration.OperationType{admissionregistration.Create}, admissionregistration.NamespacedScope)
+		if !reflect.DeepEqual(rule.APIGroups, []string{test.gvk.Group}) {
+			t.Errorf("expected API groups %v, got %v", []string{test.gvk.Group}, rule.APIGroups)
+		}
+		if !reflect.DeepEqual(rule.APIVersions, []string{test.gvk.Version}) {
+			t.Errorf("expected API versions %v, got %v", []string{test.gvk.Version}, rule.APIVersions)
+		}
+		if !reflect.DeepEqual(rule.Resources, []string{test.resource}) {
+			t.Errorf("expected resources %v for kind %s, got %v", []string{test.resource}, test.gvk.Kind, rule.Resources)
+		}
+		if rule.Scope == nil || *rule.Scope != admissionregistration.NamespacedScope {
+			t.Errorf("expected the scope to be set, got %v", rule.Scope)
+		}
+		if !reflect.DeepEqual(rule.Operations, []admissionregistration.OperationType{admissionregistration.Create}) {
+			t.Errorf("unexpected operations: %v", rule.Operations)
+		}
+	}
+}
+
+func TestRuleForGVKWithMapper(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+
+	rule, err := RuleForGVKWithMapper(mapper, gvk, []admissionregistration.OperationType{admissionregistration.Update}, admissionregistration.AllScopes)
+	if err != nil {
+		t.Fatalf("expected the rule to be built, got %v", err)
+	}
+	if !reflect.DeepEqual(rule.Resources, []string{"deployments"}) {
+		t.Errorf("expected the plural from the mapper, got %v", rule.Resources)
+	}
+
+	unknown := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}
+	if _, err := RuleForGVKWithMapper(mapper, unknown, nil, admissionregistration.AllScopes); err == nil {
+		t.Error("expected an unmapped kind to fail")
+	}
+}
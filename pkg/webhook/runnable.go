@@ -0,0 +1,173 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// defaultSecretResyncPeriod is how often the cert secret is re-checked for
+// changes when no period is configured.
+const defaultSecretResyncPeriod = time.Minute
+
+// WebhookGeneratorRunnable generates the webhook configuration objects and
+// applies them through the manager's client, so the manager lifecycle
+// controls webhook generation. When the generator options reference a cert
+// secret, the secret is watched and the objects are re-applied on change,
+// picking up rotated certificates.
+type WebhookGeneratorRunnable struct {
+	// Options are the generator options the objects are generated from.
+	Options *generatorOptions
+	// Client applies the generated objects. It is set from the manager by
+	// SetupWithManager if unset.
+	Client client.Client
+	// ResyncPeriod is how often the cert secret is re-checked for changes.
+	// Defaults to one minute.
+	ResyncPeriod time.Duration
+
+	// lastSecretVersion is the resource version of the cert secret at the
+	// last apply.
+	lastSecretVersion string
+}
+
+// SetupWithManager wires the runnable into the manager.
+func (r *WebhookGeneratorRunnable) SetupWithManager(mgr manager.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	return mgr.Add(r)
+}
+
+// Start implements manager.Runnable. It generates and applies the webhook
+// configuration objects, then re-applies them whenever the cert secret
+// changes, until the stop channel is closed.
+func (r *WebhookGeneratorRunnable) Start(stop <-chan struct{}) error {
+	if r.ResyncPeriod <= 0 {
+		r.ResyncPeriod = defaultSecretResyncPeriod
+	}
+	if err := r.generateAndApply(); err != nil {
+		return err
+	}
+	if r.Options.secret == nil {
+		// No cert secret to watch, nothing left to do.
+		<-stop
+		return nil
+	}
+	ticker := time.NewTicker(r.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			changed, err := r.secretChanged()
+			if err != nil {
+				log.Printf("webhook generator: failed to check cert secret: %v", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := r.generateAndApply(); err != nil {
+				log.Printf("webhook generator: failed to re-apply: %v", err)
+			}
+		}
+	}
+}
+
+// generateAndApply generates the objects and applies them via the client,
+// recording the cert secret version they were generated against.
+func (r *WebhookGeneratorRunnable) generateAndApply() error {
+	objects, err := r.Options.Generate()
+	if err != nil {
+		return err
+	}
+	for i := range objects {
+		if err := r.apply(objects[i]); err != nil {
+			return err
+		}
+	}
+	if r.Options.secret != nil {
+		secret, err := r.getSecret()
+		if err == nil {
+			r.lastSecretVersion = secret.ResourceVersion
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply creates the object, or updates it if it already exists.
+func (r *WebhookGeneratorRunnable) apply(obj runtime.Object) error {
+	err := r.Client.Create(context.TODO(), obj)
+	if err == nil || !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	existing := obj.DeepCopyObject()
+	key := client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	if err := r.Client.Get(context.TODO(), key, existing); err != nil {
+		return err
+	}
+	existingAccessor, err := meta.Accessor(existing)
+	if err != nil {
+		return err
+	}
+	accessor.SetResourceVersion(existingAccessor.GetResourceVersion())
+	return r.Client.Update(context.TODO(), obj)
+}
+
+// secretChanged returns true if the cert secret's resource version moved
+// since the last apply.
+func (r *WebhookGeneratorRunnable) secretChanged() (bool, error) {
+	secret, err := r.getSecret()
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return secret.ResourceVersion != r.lastSecretVersion, nil
+}
+
+// getSecret reads the cert secret referenced by the generator options.
+func (r *WebhookGeneratorRunnable) getSecret() (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{
+		Namespace: r.Options.secret.Namespace,
+		Name:      r.Options.secret.Name,
+	}
+	if err := r.Client.Get(context.TODO(), key, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+var _ manager.Runnable = &WebhookGeneratorRunnable{}
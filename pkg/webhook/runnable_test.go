@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunnableGenerateAndApply(t *testing.T) {
+	r := &WebhookGeneratorRunnable{
+		Options: NewGenerator(
+			WithService(Service{Name: "svc", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+			WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+		),
+		Client: fake.NewFakeClient(),
+	}
+	if err := r.generateAndApply(); err != nil {
+		t.Fatalf("expected generateAndApply to succeed, got %v", err)
+	}
+
+	cfg := &admissionregistration.MutatingWebhookConfiguration{}
+	key := client.ObjectKey{Name: "mutating-webhook-configuration"}
+	if err := r.Client.Get(context.TODO(), key, cfg); err != nil {
+		t.Fatalf("expected the mutating config to be applied, got %v", err)
+	}
+
+	// a second apply takes the update path
+	if err := r.generateAndApply(); err != nil {
+		t.Fatalf("expected re-applying to succeed, got %v", err)
+	}
+}
+
+func TestRunnableSecretChanged(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "webhook-cert",
+			Namespace:       "system",
+			ResourceVersion: "1",
+		},
+	}
+	r := &WebhookGeneratorRunnable{
+		Options: NewGenerator(
+			WithSecret(apitypes.NamespacedName{Name: "webhook-cert", Namespace: "system"}),
+			WithHost("localhost"),
+			WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+		),
+		Client: fake.NewFakeClient(secret),
+	}
+	if err := r.generateAndApply(); err != nil {
+		t.Fatalf("expected generateAndApply to succeed, got %v", err)
+	}
+
+	changed, err := r.secretChanged()
+	if err != nil {
+		t.Fatalf("expected secretChanged to succeed, got %v", err)
+	}
+	if changed {
+		t.Error("expected no change right after applying")
+	}
+
+	// simulate a cert rotation bumping the secret
+	secret.ResourceVersion = "2"
+	if err := r.Client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("failed to update the secret: %v", err)
+	}
+	changed, err = r.secretChanged()
+	if err != nil {
+		t.Fatalf("expected secretChanged to succeed, got %v", err)
+	}
+	if !changed {
+		t.Error("expected a change after the secret was updated")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server wraps http.Server with in-flight request tracking, so a shutdown
+// drains running admission requests instead of cutting them off. An abruptly
+// killed webhook server fails its in-flight requests and blocks cluster
+// operations for the API server's retry window; a drained one doesn't.
+type Server struct {
+	httpServer *http.Server
+	inFlight   sync.WaitGroup
+}
+
+// NewServer creates a server for the given address and handler. The handler
+// is wrapped so every request is tracked for draining.
+func NewServer(addr string, handler http.Handler) *Server {
+	s := &Server{}
+	s.httpServer = &http.Server{
+		Addr:    addr,
+		Handler: s.trackInFlight(handler),
+	}
+	return s
+}
+
+// trackInFlight counts requests entering and leaving the handler.
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Done()
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// ListenAndServe serves on the configured address until the server is shut
+// down.
+func (s *Server) ListenAndServe() error {
+	return s.httpServer.ListenAndServe()
+}
+
+// ListenAndServeTLS serves TLS with the given certificate until the server
+// is shut down.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	return s.httpServer.ListenAndServeTLS(certFile, keyFile)
+}
+
+// Serve serves on the given listener until the server is shut down.
+func (s *Server) Serve(l net.Listener) error {
+	return s.httpServer.Serve(l)
+}
+
+// GracefulShutdown stops accepting new requests and waits for the in-flight
+// ones to finish, up to drainTimeout. It returns the shutdown error, or the
+// context error when the drain did not finish in time.
+func (s *Server) GracefulShutdown(ctx context.Context, drainTimeout time.Duration) error {
+	drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+
+	drained := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(drained)
+	}()
+
+	err := s.httpServer.Shutdown(drainCtx)
+	select {
+	case <-drained:
+	case <-drainCtx.Done():
+		return drainCtx.Err()
+	}
+	return err
+}
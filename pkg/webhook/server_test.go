@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGracefulShutdownDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	var completed int32
+	server := NewServer("", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		time.Sleep(200 * time.Millisecond)
+		atomic.AddInt32(&completed, 1)
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected listening to succeed, got %v", err)
+	}
+	serveDone := make(chan error, 1)
+	go func() { serveDone <- server.Serve(listener) }()
+
+	// fire concurrent long-running requests and wait until they all entered
+	// the handler
+	const requests = 3
+	var clients sync.WaitGroup
+	var succeeded int32
+	url := fmt.Sprintf("http://%s/", listener.Addr())
+	for i := 0; i < requests; i++ {
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			resp, err := http.Get(url)
+			if err != nil {
+				t.Errorf("expected the in-flight request to complete, got %v", err)
+				return
+			}
+			resp.Body.Close() // nolint: errcheck
+			if resp.StatusCode == http.StatusOK {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		<-started
+	}
+
+	if err := server.GracefulShutdown(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("expected graceful shutdown to succeed, got %v", err)
+	}
+	if err := <-serveDone; err != http.ErrServerClosed {
+		t.Errorf("expected Serve to return ErrServerClosed, got %v", err)
+	}
+
+	clients.Wait()
+	if atomic.LoadInt32(&completed) != requests {
+		t.Errorf("expected all %d in-flight requests to complete before exit, got %d", requests, completed)
+	}
+	if atomic.LoadInt32(&succeeded) != requests {
+		t.Errorf("expected all %d in-flight requests to succeed, got %d", requests, succeeded)
+	}
+
+	// the drained server no longer accepts requests
+	if _, err := http.Get(url); err == nil {
+		t.Error("expected requests after shutdown to fail")
+	}
+}
+
+func TestGracefulShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := NewServer("", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected listening to succeed, got %v", err)
+	}
+	go server.Serve(listener) // nolint: errcheck
+	defer close(release)
+
+	go http.Get(fmt.Sprintf("http://%s/", listener.Addr())) // nolint: errcheck
+	<-started
+
+	if err := server.GracefulShutdown(context.Background(), 50*time.Millisecond); err == nil {
+		t.Error("expected shutdown to report the expired drain timeout")
+	}
+}
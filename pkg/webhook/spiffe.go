@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"path"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// clusterSPIFFEIDName is the name of the generated ClusterSPIFFEID.
+const clusterSPIFFEIDName = "webhook-server"
+
+// spiffeManagedIdentityAnnotationKey is the pod annotation the SPIRE agent
+// injector keys on to inject the workload API socket into the pod.
+const spiffeManagedIdentityAnnotationKey = "spiffe.io/spire-managed-identity"
+
+// defaultSPIREAgentSocketPath is the SPIRE agent UNIX socket path used when
+// none is configured.
+const defaultSPIREAgentSocketPath = "/run/spire/sockets/agent.sock"
+
+// SPIFFEOptions configures SPIFFE/SPIRE workload identity for the webhook
+// server. Environments using SPIFFE obtain the serving certificate from the
+// SPIRE agent workload API socket rather than a Kubernetes secret, so the
+// secret-based CA annotations are dropped from the generated configurations.
+type SPIFFEOptions struct {
+	// TrustDomain is the SPIFFE trust domain of the cluster,
+	// e.g. "example.org".
+	TrustDomain string
+	// AgentSocketPath is the path of the SPIRE agent UNIX socket the server
+	// reads its certificate from. Defaults to
+	// /run/spire/sockets/agent.sock.
+	AgentSocketPath string
+	// SpiffeID is the SPIFFE ID template to register the webhook server
+	// pods under. Defaults to
+	// spiffe://<TrustDomain>/ns/<service namespace>/<service name>.
+	SpiffeID string
+}
+
+// WithSPIFFE provisions the webhook server certificate through SPIFFE/SPIRE
+// instead of a Kubernetes secret. Generate emits a ClusterSPIFFEID
+// registering the server pods, and the generated Deployment pods are
+// annotated for SPIRE sidecar injection.
+func WithSPIFFE(s SPIFFEOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.spiffe = &s
+	}
+}
+
+// spiffeIDTemplate returns the SPIFFE ID template the server pods are
+// registered under.
+func (o *generatorOptions) spiffeIDTemplate() string {
+	if len(o.spiffe.SpiffeID) != 0 {
+		return o.spiffe.SpiffeID
+	}
+	if o.service != nil {
+		return fmt.Sprintf("spiffe://%s/ns/%s/%s", o.spiffe.TrustDomain, o.service.Namespace, o.service.Name)
+	}
+	return fmt.Sprintf("spiffe://%s/webhook-server", o.spiffe.TrustDomain)
+}
+
+// clusterSPIFFEID creates the ClusterSPIFFEID registering the webhook server
+// pods with the SPIRE server, selected by the service selectors. It returns
+// nil if SPIFFE is not configured.
+func (o *generatorOptions) clusterSPIFFEID() runtime.Object {
+	if o.spiffe == nil {
+		return nil
+	}
+	spiffeID := &spirev1alpha1.ClusterSPIFFEID{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: spirev1alpha1.SchemeGroupVersion.String(),
+			Kind:       spirev1alpha1.ClusterSPIFFEIDKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterSPIFFEIDName,
+		},
+		Spec: spirev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: o.spiffeIDTemplate(),
+		},
+	}
+	if o.service != nil {
+		if len(o.service.Selectors) != 0 {
+			spiffeID.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: o.service.Selectors}
+		}
+		if len(o.service.Namespace) != 0 {
+			spiffeID.Spec.NamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{namespaceNameLabel: o.service.Namespace},
+			}
+		}
+	}
+	return spiffeID
+}
+
+// spiffeAgentSocketVolume returns the hostPath volume and mount exposing the
+// SPIRE agent socket directory to the webhook server container.
+func (o *generatorOptions) spiffeAgentSocketVolume() (corev1.Volume, corev1.VolumeMount) {
+	socketPath := o.spiffe.AgentSocketPath
+	if len(socketPath) == 0 {
+		socketPath = defaultSPIREAgentSocketPath
+	}
+	socketDir := path.Dir(socketPath)
+	hostPathType := corev1.HostPathDirectory
+	volume := corev1.Volume{
+		Name: "spire-agent-socket",
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: socketDir,
+				Type: &hostPathType,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      "spire-agent-socket",
+		MountPath: socketDir,
+		ReadOnly:  true,
+	}
+	return volume, mount
+}
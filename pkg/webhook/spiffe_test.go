@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	spirev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func spiffeGenerator() *generatorOptions {
+	return NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithSecret(apitypes.NamespacedName{Namespace: "system", Name: "webhook-cert"}),
+		WithDeployment(DeploymentOptions{Image: "example.com/webhook-server:v1"}),
+		WithSPIFFE(SPIFFEOptions{TrustDomain: "example.org"}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+}
+
+func TestGenerateClusterSPIFFEID(t *testing.T) {
+	objects, err := spiffeGenerator().Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var spiffeID *spirev1alpha1.ClusterSPIFFEID
+	for _, obj := range objects {
+		if id, ok := obj.(*spirev1alpha1.ClusterSPIFFEID); ok {
+			spiffeID = id
+		}
+	}
+	if spiffeID == nil {
+		t.Fatal("expected a ClusterSPIFFEID to be generated")
+	}
+	if spiffeID.Spec.SPIFFEIDTemplate != "spiffe://example.org/ns/system/webhook-service" {
+		t.Errorf("expected the SPIFFE ID to default from the service, got %q", spiffeID.Spec.SPIFFEIDTemplate)
+	}
+	if spiffeID.Spec.PodSelector == nil || spiffeID.Spec.PodSelector.MatchLabels["app"] != "webhook-server" {
+		t.Errorf("expected the pod selector to match the service selectors, got %v", spiffeID.Spec.PodSelector)
+	}
+	if spiffeID.Spec.NamespaceSelector == nil || spiffeID.Spec.NamespaceSelector.MatchLabels[namespaceNameLabel] != "system" {
+		t.Errorf("expected the namespace selector to match the service namespace, got %v", spiffeID.Spec.NamespaceSelector)
+	}
+}
+
+func TestSPIFFESuppressesSecretAnnotations(t *testing.T) {
+	objects, err := spiffeGenerator().Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	for _, obj := range objects {
+		if cfg, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			if _, found := cfg.Annotations[caSecretAnnotationKey]; found {
+				t.Error("expected no ca-secret annotation with SPIFFE provisioning")
+			}
+		}
+	}
+}
+
+func TestSPIFFEDeploymentInjection(t *testing.T) {
+	objects, err := spiffeGenerator().Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	var deployment *appsv1.Deployment
+	for _, obj := range objects {
+		if d, ok := obj.(*appsv1.Deployment); ok {
+			deployment = d
+		}
+	}
+	if deployment == nil {
+		t.Fatal("expected a Deployment to be generated")
+	}
+	if deployment.Spec.Template.Annotations[spiffeManagedIdentityAnnotationKey] != "true" {
+		t.Errorf("expected the SPIRE injection annotation on the pod template, got %v", deployment.Spec.Template.Annotations)
+	}
+	mounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+	if len(mounts) != 1 || mounts[0].MountPath != "/run/spire/sockets" {
+		t.Errorf("expected the agent socket directory to be mounted, got %v", mounts)
+	}
+	if len(deployment.Spec.Template.Spec.Volumes) != 1 {
+		t.Errorf("expected the agent socket volume, got %v", deployment.Spec.Template.Spec.Volumes)
+	}
+}
+
+func TestSPIFFETrustDomainValidation(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithSPIFFE(SPIFFEOptions{}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err := o.Validate(); err == nil {
+		t.Error("expected an empty trust domain to fail validation")
+	}
+}
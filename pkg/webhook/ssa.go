@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ApplyOptions carries the server-side apply parameters for the generated
+// manifests. The vendored apimachinery predates metav1.ApplyOptions, so the
+// package carries its own equivalent for appliers to translate onto the
+// apply request.
+type ApplyOptions struct {
+	// FieldManager is the name under which the applied fields are owned.
+	FieldManager string
+	// Force resolves conflicts by taking ownership of conflicting fields.
+	Force bool
+}
+
+// GenerateSSA generates the webhook configuration objects prepared for
+// server-side apply under the given field manager: fields owned by other
+// managers (the CABundle placeholder that a cert provisioner patches) are
+// stripped so applying doesn't claim them, and each object carries the
+// kubectl last-applied-configuration annotation for clients that fall back
+// to client-side apply. The apply options to send with the request are
+// returned alongside the objects.
+func GenerateSSA(manager string, opts ...GeneratorOption) ([]runtime.Object, ApplyOptions, error) {
+	applyOptions := ApplyOptions{FieldManager: manager}
+	if len(manager) == 0 {
+		return nil, applyOptions, fmt.Errorf("field manager name must not be empty")
+	}
+	// The CABundle placeholder belongs to the cert provisioner's field
+	// manager; omit it entirely so the apply doesn't take ownership.
+	opts = append(opts, WithOmitEmptyCABundle(true))
+	o := NewGenerator(opts...)
+	objects, err := o.Generate()
+	if err != nil {
+		return nil, applyOptions, err
+	}
+	for _, obj := range objects {
+		if err := setLastAppliedAnnotation(obj); err != nil {
+			return nil, applyOptions, err
+		}
+	}
+	return objects, applyOptions, nil
+}
+
+// setLastAppliedAnnotation records the object's configuration, before the
+// annotation itself is added, in the kubectl last-applied annotation.
+func setLastAppliedAnnotation(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %s %q: %v", obj.GetObjectKind().GroupVersionKind().Kind, accessor.GetName(), err)
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[corev1.LastAppliedConfigAnnotation] = string(content)
+	accessor.SetAnnotations(annotations)
+	return nil
+}
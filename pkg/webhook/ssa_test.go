@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateSSA(t *testing.T) {
+	objects, applyOptions, err := GenerateSSA("webhook-generator",
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err != nil {
+		t.Fatalf("expected GenerateSSA to succeed, got %v", err)
+	}
+	if applyOptions.FieldManager != "webhook-generator" {
+		t.Errorf("unexpected field manager: %q", applyOptions.FieldManager)
+	}
+
+	for _, obj := range objects {
+		if mwc, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			for _, wh := range mwc.Webhooks {
+				// The placeholder belongs to the cert provisioner, so it must
+				// be stripped rather than applied as an empty value.
+				if wh.ClientConfig.CABundle != nil {
+					t.Errorf("expected the CABundle placeholder to be stripped, got %v", wh.ClientConfig.CABundle)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateSSARoundTrip(t *testing.T) {
+	objects, _, err := GenerateSSA("webhook-generator",
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if err != nil {
+		t.Fatalf("expected GenerateSSA to succeed, got %v", err)
+	}
+
+	var service *corev1.Service
+	for _, obj := range objects {
+		if typed, ok := obj.(*corev1.Service); ok {
+			service = typed
+		}
+	}
+	if service == nil {
+		t.Fatal("expected a Service to be generated")
+	}
+	lastApplied, found := service.Annotations[corev1.LastAppliedConfigAnnotation]
+	if !found {
+		t.Fatal("expected the last-applied annotation to be set")
+	}
+
+	restored := &corev1.Service{}
+	if err := json.Unmarshal([]byte(lastApplied), restored); err != nil {
+		t.Fatalf("expected the annotation to round-trip, got %v", err)
+	}
+	// The annotation records the configuration before the annotation itself
+	// was added.
+	withoutAnnotation := service.DeepCopy()
+	delete(withoutAnnotation.Annotations, corev1.LastAppliedConfigAnnotation)
+	if len(withoutAnnotation.Annotations) == 0 {
+		withoutAnnotation.Annotations = nil
+	}
+	if !reflect.DeepEqual(restored, withoutAnnotation) {
+		t.Errorf("round-tripped service differs:\nwant %+v\ngot  %+v", withoutAnnotation, restored)
+	}
+}
+
+func TestGenerateSSAEmptyManager(t *testing.T) {
+	if _, _, err := GenerateSSA(""); err == nil {
+		t.Error("expected an empty field manager to fail")
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AdmissionRequestBuilder builds AdmissionRequest objects for tests without
+// spelling out every field of the AdmissionReview envelope.
+type AdmissionRequestBuilder struct {
+	request admissionv1beta1.AdmissionRequest
+}
+
+// NewAdmissionRequestBuilder creates a builder for an AdmissionRequest.
+// The operation defaults to CREATE.
+func NewAdmissionRequestBuilder() *AdmissionRequestBuilder {
+	return &AdmissionRequestBuilder{
+		request: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+		},
+	}
+}
+
+// WithObject sets the object under admission.
+func (b *AdmissionRequestBuilder) WithObject(obj runtime.Object) *AdmissionRequestBuilder {
+	b.request.Object = runtime.RawExtension{Object: obj}
+	return b
+}
+
+// WithOldObject sets the existing object, as populated for UPDATE requests.
+func (b *AdmissionRequestBuilder) WithOldObject(obj runtime.Object) *AdmissionRequestBuilder {
+	b.request.OldObject = runtime.RawExtension{Object: obj}
+	return b
+}
+
+// WithOperation sets the operation being performed.
+func (b *AdmissionRequestBuilder) WithOperation(op admissionv1beta1.Operation) *AdmissionRequestBuilder {
+	b.request.Operation = op
+	return b
+}
+
+// WithUserInfo sets the information about the requesting user.
+func (b *AdmissionRequestBuilder) WithUserInfo(user authenticationv1.UserInfo) *AdmissionRequestBuilder {
+	b.request.UserInfo = user
+	return b
+}
+
+// WithDryRun marks the request as a dry-run request.
+func (b *AdmissionRequestBuilder) WithDryRun(dryRun bool) *AdmissionRequestBuilder {
+	b.request.DryRun = &dryRun
+	return b
+}
+
+// Build returns the built AdmissionRequest.
+func (b *AdmissionRequestBuilder) Build() *admissionv1beta1.AdmissionRequest {
+	request := b.request
+	return &request
+}
+
+// AdmissionResponseBuilder builds AdmissionResponse objects for tests.
+type AdmissionResponseBuilder struct {
+	response admissionv1beta1.AdmissionResponse
+}
+
+// NewAdmissionResponseBuilder creates a builder for an AdmissionResponse.
+func NewAdmissionResponseBuilder() *AdmissionResponseBuilder {
+	return &AdmissionResponseBuilder{}
+}
+
+// WithAllowed sets whether the admission request is allowed.
+func (b *AdmissionResponseBuilder) WithAllowed(allowed bool) *AdmissionResponseBuilder {
+	b.response.Allowed = allowed
+	return b
+}
+
+// WithPatch sets a JSONPatch-type patch on the response.
+func (b *AdmissionResponseBuilder) WithPatch(patch []byte) *AdmissionResponseBuilder {
+	b.response.Patch = patch
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	b.response.PatchType = &patchType
+	return b
+}
+
+// WithStatus sets the result status on the response.
+func (b *AdmissionResponseBuilder) WithStatus(status metav1.Status) *AdmissionResponseBuilder {
+	b.response.Result = &status
+	return b
+}
+
+// Build returns the built AdmissionResponse.
+func (b *AdmissionResponseBuilder) Build() *admissionv1beta1.AdmissionResponse {
+	response := b.response
+	return &response
+}
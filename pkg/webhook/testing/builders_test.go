@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdmissionRequestBuilder(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	oldPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"old": "true"}}}
+	req := NewAdmissionRequestBuilder().
+		WithObject(pod).
+		WithOldObject(oldPod).
+		WithOperation(admissionv1beta1.Update).
+		WithUserInfo(authenticationv1.UserInfo{Username: "jane"}).
+		WithDryRun(true).
+		Build()
+
+	if req.Object.Object != pod {
+		t.Errorf("expected object %v, got %v", pod, req.Object.Object)
+	}
+	if req.OldObject.Object != oldPod {
+		t.Errorf("expected old object %v, got %v", oldPod, req.OldObject.Object)
+	}
+	if req.Operation != admissionv1beta1.Update {
+		t.Errorf("expected operation %v, got %v", admissionv1beta1.Update, req.Operation)
+	}
+	if req.UserInfo.Username != "jane" {
+		t.Errorf("expected username jane, got %q", req.UserInfo.Username)
+	}
+	if req.DryRun == nil || !*req.DryRun {
+		t.Errorf("expected dry run to be set to true, got %v", req.DryRun)
+	}
+}
+
+func TestAdmissionRequestBuilderDefaults(t *testing.T) {
+	req := NewAdmissionRequestBuilder().Build()
+	if req.Operation != admissionv1beta1.Create {
+		t.Errorf("expected operation to default to %v, got %v", admissionv1beta1.Create, req.Operation)
+	}
+	if req.DryRun != nil {
+		t.Errorf("expected dry run to be unset, got %v", *req.DryRun)
+	}
+}
+
+func TestAdmissionResponseBuilder(t *testing.T) {
+	patch := []byte(`[{"op":"add","path":"/metadata/labels","value":{}}]`)
+	status := metav1.Status{Message: "not allowed"}
+	resp := NewAdmissionResponseBuilder().
+		WithAllowed(false).
+		WithPatch(patch).
+		WithStatus(status).
+		Build()
+
+	if resp.Allowed {
+		t.Error("expected the response to be disallowed")
+	}
+	if string(resp.Patch) != string(patch) {
+		t.Errorf("expected patch %s, got %s", patch, resp.Patch)
+	}
+	if resp.PatchType == nil || *resp.PatchType != admissionv1beta1.PatchTypeJSONPatch {
+		t.Errorf("expected patch type %v, got %v", admissionv1beta1.PatchTypeJSONPatch, resp.PatchType)
+	}
+	if resp.Result == nil || resp.Result.Message != "not allowed" {
+		t.Errorf("expected status message %q, got %v", status.Message, resp.Result)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing provides helpers for unit testing admission webhook
+// handlers without spinning up a real webhook server.
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// Webhook pairs a webhook path with the http.Handler serving it.
+type Webhook struct {
+	// Path is the path the handler is registered under.
+	Path string
+	// Handler handles the admission requests sent to Path.
+	Handler http.Handler
+}
+
+// NewFakeWebhookServer starts an in-process httptest.Server with all the
+// given webhooks registered. The returned server is ready immediately and
+// serves plain HTTP (no TLS), which is sufficient for unit tests; call
+// Close() on it when the test is done.
+func NewFakeWebhookServer(webhooks ...Webhook) (*httptest.Server, error) {
+	mux := http.NewServeMux()
+	registered := map[string]bool{}
+	for _, wh := range webhooks {
+		if len(wh.Path) == 0 || !strings.HasPrefix(wh.Path, "/") {
+			return nil, fmt.Errorf("webhook path %q should start with /", wh.Path)
+		}
+		if wh.Handler == nil {
+			return nil, fmt.Errorf("webhook %q should have a handler", wh.Path)
+		}
+		if registered[wh.Path] {
+			return nil, fmt.Errorf("can't register duplicate path: %v", wh.Path)
+		}
+		registered[wh.Path] = true
+		mux.Handle(wh.Path, wh.Handler)
+	}
+	return httptest.NewServer(mux), nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestNewFakeWebhookServer(t *testing.T) {
+	server, err := NewFakeWebhookServer(Webhook{
+		Path: "/mutate-pods",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok")) // nolint: errcheck
+		}),
+	})
+	if err != nil {
+		t.Fatalf("expected the fake server to start, got %v", err)
+	}
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/mutate-pods")
+	if err != nil {
+		t.Fatalf("expected the request to succeed, got %v", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("expected response ok, got %q", string(body))
+	}
+}
+
+func TestNewFakeWebhookServerInvalid(t *testing.T) {
+	if _, err := NewFakeWebhookServer(Webhook{Path: "no-slash", Handler: http.NotFoundHandler()}); err == nil {
+		t.Error("expected an invalid path to fail")
+	}
+	if _, err := NewFakeWebhookServer(Webhook{Path: "/a"}); err == nil {
+		t.Error("expected a missing handler to fail")
+	}
+	if _, err := NewFakeWebhookServer(
+		Webhook{Path: "/a", Handler: http.NotFoundHandler()},
+		Webhook{Path: "/a", Handler: http.NotFoundHandler()},
+	); err == nil {
+		t.Error("expected duplicate paths to fail")
+	}
+}
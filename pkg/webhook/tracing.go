@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// tracingConfigMapName is the name of the generated ConfigMap holding the
+// OpenTelemetry collector configuration.
+const tracingConfigMapName = "otel-collector-config"
+
+// tracingConfigKey is the key of the collector configuration in the
+// generated ConfigMap.
+const tracingConfigKey = "collector.yaml"
+
+// TracingOptions configures the generation of an OpenTelemetry collector
+// sidecar configuration for the webhook server.
+type TracingOptions struct {
+	// ExporterEndpoint is the OTLP endpoint the collector exports spans to,
+	// e.g. otel-backend.observability:4317.
+	ExporterEndpoint string
+	// SamplingRate is the probabilistic sampling percentage, 0-100.
+	SamplingRate float64
+	// ServiceName is the service name the spans are reported under.
+	ServiceName string
+}
+
+// WithTracing enables generating an OpenTelemetry collector configuration
+// for the webhook server.
+func WithTracing(t TracingOptions) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.tracing = &t
+	}
+}
+
+// tracingConfigMap creates the ConfigMap holding the OTEL collector
+// configuration. It returns nil if tracing is not configured.
+func (o *generatorOptions) tracingConfigMap() runtime.Object {
+	if o.tracing == nil {
+		return nil
+	}
+	namespace := ""
+	if o.service != nil {
+		namespace = o.service.Namespace
+	}
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tracingConfigMapName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			tracingConfigKey: o.tracing.collectorConfig(),
+		},
+	}
+}
+
+// collectorConfig renders the OTEL collector configuration.
+func (t *TracingOptions) collectorConfig() string {
+	return fmt.Sprintf(`receivers:
+  otlp:
+    protocols:
+      grpc:
+processors:
+  probabilistic_sampler:
+    sampling_percentage: %g
+  resource:
+    attributes:
+    - key: service.name
+      value: %s
+      action: upsert
+exporters:
+  otlp:
+    endpoint: %s
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [probabilistic_sampler, resource]
+      exporters: [otlp]
+`, t.SamplingRate, t.ServiceName, t.ExporterEndpoint)
+}
+
+// AppendTracingSidecar appends the OTEL collector sidecar container to the
+// given pod spec, mounting the generated collector ConfigMap. It is a no-op
+// if tracing is not configured. The generator does not emit the Deployment
+// itself; this hook is for callers that maintain their own Deployment
+// manifest for the webhook server.
+func (o *generatorOptions) AppendTracingSidecar(spec *corev1.PodSpec) {
+	if o.tracing == nil {
+		return
+	}
+	volume := corev1.Volume{
+		Name: tracingConfigMapName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: tracingConfigMapName},
+			},
+		},
+	}
+	spec.Volumes = append(spec.Volumes, volume)
+	spec.Containers = append(spec.Containers, corev1.Container{
+		Name:  "otel-collector",
+		Image: "otel/opentelemetry-collector",
+		Args:  []string{"--config=/conf/" + tracingConfigKey},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      tracingConfigMapName,
+				MountPath: "/conf",
+			},
+		},
+	})
+}
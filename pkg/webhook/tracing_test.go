@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGenerateTracingConfigMap(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithTracing(TracingOptions{
+			ExporterEndpoint: "otel-backend.observability:4317",
+			SamplingRate:     25,
+			ServiceName:      "webhook-server",
+		}),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+
+	var cm *corev1.ConfigMap
+	for _, obj := range objects {
+		if typed, ok := obj.(*corev1.ConfigMap); ok {
+			cm = typed
+		}
+	}
+	if cm == nil {
+		t.Fatal("expected a collector ConfigMap to be generated")
+	}
+	if cm.Name != tracingConfigMapName || cm.Namespace != "test-system" {
+		t.Errorf("unexpected ConfigMap metadata: %s/%s", cm.Namespace, cm.Name)
+	}
+	config := cm.Data[tracingConfigKey]
+	if !strings.Contains(config, "endpoint: otel-backend.observability:4317") {
+		t.Errorf("expected the OTLP exporter endpoint in the config, got:\n%s", config)
+	}
+	if !strings.Contains(config, "sampling_percentage: 25") {
+		t.Errorf("expected the sampling rate in the config, got:\n%s", config)
+	}
+	if !strings.Contains(config, "value: webhook-server") {
+		t.Errorf("expected the service name in the config, got:\n%s", config)
+	}
+}
+
+func TestAppendTracingSidecar(t *testing.T) {
+	o := NewGenerator(WithTracing(TracingOptions{
+		ExporterEndpoint: "otel-backend.observability:4317",
+		ServiceName:      "webhook-server",
+	}))
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "webhook-server"}},
+	}
+	o.AppendTracingSidecar(&spec)
+	if len(spec.Containers) != 2 || spec.Containers[1].Name != "otel-collector" {
+		t.Fatalf("expected the collector sidecar to be appended, got %+v", spec.Containers)
+	}
+	if len(spec.Volumes) != 1 || spec.Volumes[0].ConfigMap.Name != tracingConfigMapName {
+		t.Errorf("expected the collector config volume, got %+v", spec.Volumes)
+	}
+
+	// Without tracing options the pod spec is left alone.
+	spec = corev1.PodSpec{}
+	NewGenerator().AppendTracingSidecar(&spec)
+	if len(spec.Containers) != 0 {
+		t.Errorf("expected no sidecar without tracing options, got %+v", spec.Containers)
+	}
+}
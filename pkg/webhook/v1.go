@@ -0,0 +1,257 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// This file holds the admissionregistration/v1 output path. The v1 API has
+// fields that v1beta1 lacks (e.g. matchConditions), so the registered
+// webhooks are converted to the v1 entry types separately instead of being
+// shoehorned through the v1beta1 conversion.
+
+// validatingWebhookV1 converts the registered webhook to a v1
+// ValidatingWebhook entry. Unlike the v1beta1 path, matchConditions are
+// emitted here; they require Kubernetes 1.28+.
+func (o *generatorOptions) validatingWebhookV1(path string, wh *admissionWebhook) (*admissionregistrationv1.ValidatingWebhook, error) {
+	if err := wh.Validate(); err != nil {
+		o.metrics.IncValidationError(path)
+		return nil, err
+	}
+	if len(wh.MatchConditions) != 0 && !o.supportsK8sVersion(1, 28) {
+		return nil, fmt.Errorf("webhook %q sets matchConditions, which is not supported in kubernetes %s (requires 1.28+)", wh.Name, o.targetK8sVersion)
+	}
+	servePath, objectSelector, err := o.routingForWebhook(path, wh)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := o.getV1ClientConfigWithPath(servePath)
+	if err != nil {
+		return nil, err
+	}
+	if wh.URL != nil {
+		urlString := *wh.URL
+		cc = &admissionregistrationv1.WebhookClientConfig{
+			CABundle: o.placeholderCABundle(),
+			URL:      &urlString,
+		}
+		if err := setV1Path(cc, servePath); err != nil {
+			return nil, err
+		}
+	}
+	namespaceSelector, err := o.namespaceSelectorFor(wh)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &admissionregistrationv1.ValidatingWebhook{
+		Name:              wh.Name,
+		ClientConfig:      *cc,
+		Rules:             convertRulesToV1(wh.Rules, wh.Scope),
+		NamespaceSelector: namespaceSelector,
+		ObjectSelector:    objectSelector,
+		MatchConditions:   wh.MatchConditions,
+		// The v1 webhook server speaks the v1beta1 AdmissionReview.
+		AdmissionReviewVersions: []string{"v1beta1"},
+	}
+	if wh.FailurePolicy != nil {
+		policy := admissionregistrationv1.FailurePolicyType(*wh.FailurePolicy)
+		webhook.FailurePolicy = &policy
+	}
+	if wh.SideEffects != nil {
+		sideEffects := admissionregistrationv1.SideEffectClass(*wh.SideEffects)
+		webhook.SideEffects = &sideEffects
+	}
+	return webhook, nil
+}
+
+// mutatingWebhookV1 converts the registered webhook to a v1 MutatingWebhook
+// entry.
+func (o *generatorOptions) mutatingWebhookV1(path string, wh *admissionWebhook) (*admissionregistrationv1.MutatingWebhook, error) {
+	vwh, err := o.validatingWebhookV1(path, wh)
+	if err != nil {
+		return nil, err
+	}
+	mwh := &admissionregistrationv1.MutatingWebhook{
+		Name:                    vwh.Name,
+		ClientConfig:            vwh.ClientConfig,
+		Rules:                   vwh.Rules,
+		FailurePolicy:           vwh.FailurePolicy,
+		NamespaceSelector:       vwh.NamespaceSelector,
+		ObjectSelector:          vwh.ObjectSelector,
+		SideEffects:             vwh.SideEffects,
+		AdmissionReviewVersions: vwh.AdmissionReviewVersions,
+		MatchConditions:         vwh.MatchConditions,
+	}
+	policy := wh.ReinvocationPolicy
+	if policy == nil {
+		policy = o.defaultReinvocationPolicy
+	}
+	if policy != nil {
+		v1Policy := admissionregistrationv1.ReinvocationPolicyType(*policy)
+		mwh.ReinvocationPolicy = &v1Policy
+	}
+	return mwh, nil
+}
+
+// whConfigsV1 creates the v1 webhook configuration objects from the
+// registry, mirroring whConfigs for the v1beta1 API.
+func (o *generatorOptions) whConfigsV1() ([]runtime.Object, error) {
+	objs := []runtime.Object{}
+	mutatingWH, err := o.mutatingWHConfigV1()
+	if err != nil {
+		return nil, err
+	}
+	if mutatingWH != nil {
+		objs = append(objs, mutatingWH)
+	}
+	validatingWH, err := o.validatingWHConfigV1()
+	if err != nil {
+		return nil, err
+	}
+	if validatingWH != nil {
+		objs = append(objs, validatingWH)
+	}
+	return objs, nil
+}
+
+// mutatingWHConfigV1 creates the v1 MutatingWebhookConfiguration object. It
+// returns nil if no mutating webhook is registered.
+func (o *generatorOptions) mutatingWHConfigV1() (runtime.Object, error) {
+	type orderedWebhook struct {
+		order   int
+		webhook admissionregistrationv1.MutatingWebhook
+	}
+	ordered := []orderedWebhook{}
+	for path, webhook := range o.registry {
+		if !webhook.Type.matches(webhookTypeMutating) || webhook.disabled() {
+			continue
+		}
+		for _, expanded := range webhook.expandFailurePolicies() {
+			wh, err := o.mutatingWebhookV1(path, expanded)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q at path %q: %w", expanded.Name, path, err)
+			}
+			wh.Name = expanded.nameFor(webhookTypeMutating)
+			if wh.ClientConfig.Service != nil {
+				wh.ClientConfig.Service.Name = o.serviceNameFor(webhookTypeMutating)
+			}
+			if bundle := o.caBundleFor(webhookTypeMutating); len(bundle) != 0 {
+				wh.ClientConfig.CABundle = bundle
+			}
+			o.metrics.IncGenerated(webhookTypeMutating.String())
+			ordered = append(ordered, orderedWebhook{order: webhook.Order, webhook: *wh})
+		}
+	}
+
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+	// Mirror the v1beta1 builder: emit the entries in declared order with a
+	// name fallback, not map iteration order.
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].order != ordered[j].order {
+			return ordered[i].order < ordered[j].order
+		}
+		return ordered[i].webhook.Name < ordered[j].webhook.Name
+	})
+	mutatingWebhooks := make([]admissionregistrationv1.MutatingWebhook, len(ordered))
+	for i := range ordered {
+		mutatingWebhooks[i] = ordered[i].webhook
+	}
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fmt.Sprintf("%s/%s", o.webhookConfigGroup, "v1"),
+			Kind:       "MutatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        o.mutatingWebhookConfigName,
+			Annotations: o.configAnnotations(webhookTypeMutating),
+		},
+		Webhooks: mutatingWebhooks,
+	}, nil
+}
+
+// validatingWHConfigV1 creates the v1 ValidatingWebhookConfiguration object.
+// It returns nil if no validating webhook is registered.
+func (o *generatorOptions) validatingWHConfigV1() (runtime.Object, error) {
+	validatingWebhooks := []admissionregistrationv1.ValidatingWebhook{}
+	for path, webhook := range o.registry {
+		if !webhook.Type.matches(webhookTypeValidating) || webhook.disabled() {
+			continue
+		}
+		for _, expanded := range webhook.expandFailurePolicies() {
+			wh, err := o.validatingWebhookV1(path, expanded)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q at path %q: %w", expanded.Name, path, err)
+			}
+			wh.Name = expanded.nameFor(webhookTypeValidating)
+			if wh.ClientConfig.Service != nil {
+				wh.ClientConfig.Service.Name = o.serviceNameFor(webhookTypeValidating)
+			}
+			if bundle := o.caBundleFor(webhookTypeValidating); len(bundle) != 0 {
+				wh.ClientConfig.CABundle = bundle
+			}
+			o.metrics.IncGenerated(webhookTypeValidating.String())
+			validatingWebhooks = append(validatingWebhooks, *wh)
+		}
+	}
+
+	if len(validatingWebhooks) == 0 {
+		return nil, nil
+	}
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fmt.Sprintf("%s/%s", o.webhookConfigGroup, "v1"),
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        o.validatingWebhookConfigName,
+			Annotations: o.configAnnotations(webhookTypeValidating),
+		},
+		Webhooks: validatingWebhooks,
+	}, nil
+}
+
+// convertRulesToV1 converts the v1beta1 rules to their v1 equivalents,
+// applying the webhook-level scope if set.
+func convertRulesToV1(rules []admissionregistration.RuleWithOperations, scope *admissionregistration.ScopeType) []admissionregistrationv1.RuleWithOperations {
+	converted := make([]admissionregistrationv1.RuleWithOperations, len(rules))
+	for i, rule := range rules {
+		for _, op := range rule.Operations {
+			converted[i].Operations = append(converted[i].Operations, admissionregistrationv1.OperationType(op))
+		}
+		converted[i].APIGroups = append([]string(nil), rule.APIGroups...)
+		converted[i].APIVersions = append([]string(nil), rule.APIVersions...)
+		converted[i].Resources = append([]string(nil), rule.Resources...)
+		ruleScope := rule.Scope
+		if scope != nil {
+			ruleScope = scope
+		}
+		if ruleScope != nil {
+			v1Scope := admissionregistrationv1.ScopeType(*ruleScope)
+			converted[i].Scope = &v1Scope
+		}
+	}
+	return converted
+}
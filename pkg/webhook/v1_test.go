@@ -0,0 +1,235 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestValidatingWebhookV1MatchConditions(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	wh.MatchConditions = []admissionregistrationv1.MatchCondition{
+		{Name: "exclude-system", Expression: `!(request.userInfo.username.startsWith("system:"))`},
+	}
+
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "test-system"}),
+		WithWebhook(wh),
+	)
+	entry, err := o.validatingWebhookV1("/validate-pods", wh)
+	if err != nil {
+		t.Fatalf("expected the v1 conversion to succeed, got %v", err)
+	}
+	if len(entry.MatchConditions) != 1 || entry.MatchConditions[0].Name != "exclude-system" {
+		t.Errorf("expected the match condition to be emitted, got %v", entry.MatchConditions)
+	}
+	if entry.ClientConfig.Service == nil || entry.ClientConfig.Service.Name != "webhook-service" {
+		t.Errorf("unexpected client config: %+v", entry.ClientConfig)
+	}
+	if len(entry.Rules) != 1 || entry.Rules[0].Resources[0] != "deployments" {
+		t.Errorf("unexpected rules: %+v", entry.Rules)
+	}
+
+	// matchConditions are rejected for target versions before 1.28.
+	o = NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "test-system"}),
+		WithTargetK8sVersion("1.27"),
+		WithWebhook(wh),
+	)
+	if _, err := o.validatingWebhookV1("/validate-pods", wh); err == nil {
+		t.Error("expected matchConditions to be rejected for kubernetes 1.27")
+	}
+}
+
+func TestMutatingWebhookV1(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "test-system"}),
+		WithWebhook(wh),
+	)
+	entry, err := o.mutatingWebhookV1("/mutate-pods", wh)
+	if err != nil {
+		t.Fatalf("expected the v1 conversion to succeed, got %v", err)
+	}
+	if entry.Name != wh.Name {
+		t.Errorf("expected name %q, got %q", wh.Name, entry.Name)
+	}
+	if len(entry.AdmissionReviewVersions) == 0 {
+		t.Error("expected admissionReviewVersions to be set")
+	}
+}
+
+func TestGenerateMultiVersion(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithWebhook(
+			testMutatingWebhook("mutatepods.example.com", "/mutate-pods"),
+			testValidatingWebhook("validatepods.example.com", "/validate-pods"),
+		),
+	)
+	output, err := o.GenerateMultiVersion([]string{"v1beta1", "v1"})
+	if err != nil {
+		t.Fatalf("expected GenerateMultiVersion to succeed, got %v", err)
+	}
+	if len(output) != 2 {
+		t.Fatalf("expected 2 object sets, got %d", len(output))
+	}
+
+	var v1beta1Mutating *admissionregistration.MutatingWebhookConfiguration
+	for _, obj := range output["v1beta1"] {
+		if cfg, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			v1beta1Mutating = cfg
+		}
+	}
+	if v1beta1Mutating == nil {
+		t.Fatal("expected the v1beta1 set to contain a MutatingWebhookConfiguration")
+	}
+
+	var v1Mutating *admissionregistrationv1.MutatingWebhookConfiguration
+	var v1Validating *admissionregistrationv1.ValidatingWebhookConfiguration
+	for _, obj := range output["v1"] {
+		switch cfg := obj.(type) {
+		case *admissionregistrationv1.MutatingWebhookConfiguration:
+			v1Mutating = cfg
+		case *admissionregistrationv1.ValidatingWebhookConfiguration:
+			v1Validating = cfg
+		}
+	}
+	if v1Mutating == nil || v1Validating == nil {
+		t.Fatal("expected the v1 set to contain both configuration kinds")
+	}
+	if v1Mutating.APIVersion != "admissionregistration.k8s.io/v1" {
+		t.Errorf("expected the v1 apiVersion, got %q", v1Mutating.APIVersion)
+	}
+	// the two outputs stay in lockstep
+	if v1Mutating.Webhooks[0].Name != v1beta1Mutating.Webhooks[0].Name {
+		t.Errorf("expected matching webhook names across versions, got %q and %q",
+			v1Mutating.Webhooks[0].Name, v1beta1Mutating.Webhooks[0].Name)
+	}
+	if v1Mutating.Webhooks[0].ClientConfig.Service == nil {
+		t.Fatal("expected the v1 entry to carry the service client config")
+	}
+}
+
+func TestGenerateMultiVersionUnsupported(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	if _, err := o.GenerateMultiVersion([]string{"v2"}); err == nil {
+		t.Error("expected an unsupported version to be rejected")
+	}
+	if _, err := o.GenerateMultiVersion(nil); err == nil {
+		t.Error("expected an empty version list to be rejected")
+	}
+	if _, err := o.GenerateMultiVersion([]string{"v1", "v1"}); err == nil {
+		t.Error("expected a duplicate version to be rejected")
+	}
+}
+
+func TestMultiVersionLabelRoutingLockstep(t *testing.T) {
+	o := NewGenerator(
+		WithService(Service{
+			Name:      "webhook-service",
+			Namespace: "test-system",
+			Selectors: map[string]string{"app": "webhook-server"},
+		}),
+		WithLabelRouting(true),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	output, err := o.GenerateMultiVersion([]string{"v1beta1", "v1"})
+	if err != nil {
+		t.Fatalf("expected GenerateMultiVersion to succeed, got %v", err)
+	}
+
+	var v1beta1Entry *admissionregistration.Webhook
+	for _, obj := range output["v1beta1"] {
+		if cfg, ok := obj.(*admissionregistration.MutatingWebhookConfiguration); ok {
+			v1beta1Entry = &cfg.Webhooks[0]
+		}
+	}
+	var v1Entry *admissionregistrationv1.MutatingWebhook
+	for _, obj := range output["v1"] {
+		if cfg, ok := obj.(*admissionregistrationv1.MutatingWebhookConfiguration); ok {
+			v1Entry = &cfg.Webhooks[0]
+		}
+	}
+	if v1beta1Entry == nil || v1Entry == nil {
+		t.Fatal("expected both versions to contain a MutatingWebhookConfiguration")
+	}
+
+	// Both versions route through the shared path.
+	if *v1beta1Entry.ClientConfig.Service.Path != labelRoutingPath {
+		t.Errorf("expected the v1beta1 entry on the routing path, got %q", *v1beta1Entry.ClientConfig.Service.Path)
+	}
+	if *v1Entry.ClientConfig.Service.Path != *v1beta1Entry.ClientConfig.Service.Path {
+		t.Errorf("expected matching paths across versions, got %q and %q",
+			*v1Entry.ClientConfig.Service.Path, *v1beta1Entry.ClientConfig.Service.Path)
+	}
+	// Both versions carry the routing label selector.
+	if v1beta1Entry.ObjectSelector == nil ||
+		v1beta1Entry.ObjectSelector.MatchLabels[labelRoutingHandlerKey] != v1beta1Entry.Name {
+		t.Errorf("expected the v1beta1 routing selector, got %v", v1beta1Entry.ObjectSelector)
+	}
+	if v1Entry.ObjectSelector == nil ||
+		v1Entry.ObjectSelector.MatchLabels[labelRoutingHandlerKey] != v1beta1Entry.ObjectSelector.MatchLabels[labelRoutingHandlerKey] {
+		t.Errorf("expected matching routing selectors across versions, got %v and %v",
+			v1Entry.ObjectSelector, v1beta1Entry.ObjectSelector)
+	}
+}
+
+func TestBypassAnnotationV1(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.BypassAnnotationKey = "webhook.example.com/bypass"
+	wh.BypassAnnotationValue = "true"
+
+	o := NewGenerator(
+		WithService(Service{Name: "webhook-service", Namespace: "system", Selectors: map[string]string{"app": "webhook-server"}}),
+		WithWebhook(wh),
+	)
+	configs, err := o.whConfigsV1()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	mwc, ok := configs[0].(*admissionregistrationv1.MutatingWebhookConfiguration)
+	if !ok {
+		t.Fatalf("expected a MutatingWebhookConfiguration, got %T", configs[0])
+	}
+	objectSelector := mwc.Webhooks[0].ObjectSelector
+	if objectSelector == nil {
+		t.Fatal("expected the v1 entry to carry the bypass requirement")
+	}
+	selector, err := metav1.LabelSelectorAsSelector(objectSelector)
+	if err != nil {
+		t.Fatalf("expected the selector to convert, got %v", err)
+	}
+	if selector.Matches(labels.Set{"webhook.example.com/bypass": "true"}) {
+		t.Error("expected bypass-annotated objects to be excluded")
+	}
+	if !selector.Matches(labels.Set{}) {
+		t.Error("expected unannotated objects to match")
+	}
+}
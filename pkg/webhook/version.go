@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// k8sVersion is a parsed Kubernetes minor version, e.g. 1.14.
+type k8sVersion struct {
+	major int
+	minor int
+}
+
+// parseK8sVersion parses a Kubernetes version string of the form "1.14" or
+// "v1.14". A patch version, if present, is ignored.
+func parseK8sVersion(s string) (k8sVersion, error) {
+	v := strings.TrimPrefix(s, "v")
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 {
+		return k8sVersion{}, fmt.Errorf("invalid kubernetes version %q, expected <major>.<minor>", s)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return k8sVersion{}, fmt.Errorf("invalid kubernetes major version in %q: %v", s, err)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return k8sVersion{}, fmt.Errorf("invalid kubernetes minor version in %q: %v", s, err)
+	}
+	return k8sVersion{major: major, minor: minor}, nil
+}
+
+// atLeast returns true if v is the given version or newer.
+func (v k8sVersion) atLeast(major, minor int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	return v.minor >= minor
+}
+
+// WithTargetK8sVersion sets the Kubernetes minor version, e.g. "1.14", that
+// the generated configuration must apply cleanly to. When set, fields that
+// are not supported in that version are omitted from the generated
+// configuration, and generation fails if such a field was explicitly set on
+// a webhook.
+func WithTargetK8sVersion(version string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.targetK8sVersion = version
+	}
+}
+
+// supportsK8sVersion returns true if the target Kubernetes version is the
+// given version or newer. If no target version is set, all fields are
+// assumed to be supported.
+func (o *generatorOptions) supportsK8sVersion(major, minor int) bool {
+	if len(o.targetK8sVersion) == 0 {
+		return true
+	}
+	v, err := parseK8sVersion(o.targetK8sVersion)
+	if err != nil {
+		// Validate reports unparsable versions before generation happens.
+		return true
+	}
+	return v.atLeast(major, minor)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import "testing"
+
+func TestParseK8sVersion(t *testing.T) {
+	tests := []struct {
+		in    string
+		major int
+		minor int
+		valid bool
+	}{
+		{in: "1.14", major: 1, minor: 14, valid: true},
+		{in: "v1.16", major: 1, minor: 16, valid: true},
+		{in: "1.14.3", major: 1, minor: 14, valid: true},
+		{in: "1", valid: false},
+		{in: "one.two", valid: false},
+	}
+	for _, test := range tests {
+		v, err := parseK8sVersion(test.in)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("expected parsing %q to fail", test.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected parsing %q to succeed, got %v", test.in, err)
+			continue
+		}
+		if v.major != test.major || v.minor != test.minor {
+			t.Errorf("expected %q to parse as %d.%d, got %d.%d", test.in, test.major, test.minor, v.major, v.minor)
+		}
+	}
+}
+
+func TestSupportsK8sVersion(t *testing.T) {
+	o := NewGenerator(WithTargetK8sVersion("1.14"), WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	if err := o.Validate(); err != nil {
+		t.Fatalf("expected options to be valid, got %v", err)
+	}
+	if !o.supportsK8sVersion(1, 14) {
+		t.Error("expected 1.14 target to support 1.14 fields")
+	}
+	if o.supportsK8sVersion(1, 15) {
+		t.Error("expected 1.14 target to not support 1.15 fields")
+	}
+
+	o = NewGenerator(WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	if !o.supportsK8sVersion(1, 27) {
+		t.Error("expected unset target version to support all fields")
+	}
+
+	o = NewGenerator(WithTargetK8sVersion("bogus"), WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")))
+	if err := o.Validate(); err == nil {
+		t.Error("expected an unparsable target version to fail validation")
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// knownSubresources are the subresources served by the built-in APIs. A
+// subresource outside this set is not necessarily wrong (aggregated APIs can
+// serve their own), but for built-in resources it is usually a typo.
+var knownSubresources = map[string]bool{
+	"approval":    true,
+	"attach":      true,
+	"binding":     true,
+	"eviction":    true,
+	"exec":        true,
+	"finalize":    true,
+	"log":         true,
+	"portforward": true,
+	"proxy":       true,
+	"scale":       true,
+	"status":      true,
+	"token":       true,
+}
+
+// Warnings reports conditions in the registered webhooks that are not errors
+// but likely misconfigurations, e.g. combinations that break dry-run
+// requests. Unlike Validate, warnings never fail generation.
+func (o *generatorOptions) Warnings() []string {
+	var warnings []string
+	for path, wh := range o.registry {
+		warnings = append(warnings, wh.warnings(path)...)
+	}
+	// Webhooks watching DELETE receive only oldObject; handlers that assume
+	// object is present panic at runtime. Warn so authors check their
+	// handler, unless the warning is suppressed.
+	if !o.suppressDeleteWarning {
+		for path, wh := range o.registry {
+			for _, rule := range wh.Rules {
+				if hasDeleteOperation(rule.Operations) {
+					warnings = append(warnings, fmt.Sprintf(
+						"webhook %q (path %s) watches DELETE, whose admission requests carry only oldObject; make sure the handler does not assume object is present",
+						wh.Name, path))
+					break
+				}
+			}
+		}
+	}
+	// The control-plane label is what namespace-selector defaulting keys on
+	// to exempt the control plane; using it as a service selector is almost
+	// always a typo.
+	if o.service != nil {
+		if _, found := o.service.Selectors[controlPlaneLabel]; found {
+			warnings = append(warnings, fmt.Sprintf(
+				"service %q selects on the reserved control-plane key, which namespace-selector defaulting also keys on; this is likely a typo",
+				o.service.Name))
+		}
+	}
+	return warnings
+}
+
+// SelfInterceptionWarnings reports registered webhooks whose rules match one
+// of the resources the controller itself reconciles without a namespace
+// selector to exclude the controller's namespace. Such a webhook can deadlock
+// the controller during startup: the webhook server isn't serving yet, but
+// admission of its own resources already depends on it.
+func (o *generatorOptions) SelfInterceptionWarnings(controllerGVRs []schema.GroupVersionResource) []string {
+	var warnings []string
+	for path, wh := range o.registry {
+		if wh.disabled() || wh.NamespaceSelector != nil {
+			continue
+		}
+		for _, gvr := range controllerGVRs {
+			if wh.matchesGVR(gvr) {
+				warnings = append(warnings, fmt.Sprintf(
+					"webhook %q (path %s) intercepts %v, which its own controller reconciles; add a namespaceSelector excluding the controller's namespace or the rollout can deadlock",
+					wh.Name, path, gvr))
+			}
+		}
+	}
+	return warnings
+}
+
+// matchesGVR returns true if any rule of the webhook matches the given
+// group/version/resource, honoring wildcards.
+func (w *admissionWebhook) matchesGVR(gvr schema.GroupVersionResource) bool {
+	for _, rule := range w.Rules {
+		if ruleEntryMatches(rule.APIGroups, gvr.Group) &&
+			ruleEntryMatches(rule.APIVersions, gvr.Version) &&
+			ruleEntryMatches(rule.Resources, gvr.Resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleEntryMatches returns true if the rule entries contain the value or the
+// wildcard.
+func ruleEntryMatches(entries []string, value string) bool {
+	for _, entry := range entries {
+		if entry == "*" || entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// warnings reports likely misconfigurations of the webhook.
+func (w *admissionWebhook) warnings(path string) []string {
+	var warnings []string
+	// Deprecated webhooks keep working; the warning keeps the phase-out
+	// visible on every generation run.
+	if w.Deprecated {
+		warning := fmt.Sprintf("webhook %q (path %s) is deprecated", w.Name, path)
+		if len(w.DeprecationMessage) != 0 {
+			warning += ": " + w.DeprecationMessage
+		}
+		warnings = append(warnings, warning)
+	}
+	// The API server rejects dry-run requests that match a webhook
+	// declaring sideEffects: Some. CREATE and UPDATE are the operations
+	// commonly exercised with dry-run.
+	if w.SideEffects != nil && *w.SideEffects == admissionregistration.SideEffectClassSome {
+		for _, rule := range w.Rules {
+			if hasDryRunOperation(rule.Operations) {
+				warnings = append(warnings, fmt.Sprintf(
+					"webhook %q (path %s) declares sideEffects: Some on CREATE/UPDATE operations, which makes dry-run requests fail; consider NoneOnDryRun",
+					w.Name, path))
+				break
+			}
+		}
+	}
+	// An unknown subresource on a rule entry is usually a typo (e.g.
+	// pods/stauts) and silently matches nothing.
+	for _, rule := range w.Rules {
+		for _, resource := range rule.Resources {
+			parts := strings.Split(resource, "/")
+			if len(parts) != 2 || parts[1] == "*" {
+				continue
+			}
+			if !knownSubresources[parts[1]] {
+				warnings = append(warnings, fmt.Sprintf(
+					"webhook %q (path %s) intercepts unknown subresource %q; check for a typo unless an aggregated API serves it",
+					w.Name, path, resource))
+			}
+		}
+	}
+	// The wildcard operation already subsumes all operations, so explicit
+	// entries next to it are redundant.
+	for _, rule := range w.Rules {
+		if len(rule.Operations) > 1 && hasWildcardOperation(rule.Operations) {
+			warnings = append(warnings, fmt.Sprintf(
+				"webhook %q (path %s) mixes the wildcard operation '*' with explicit operations %v in one rule; the explicit entries are redundant",
+				w.Name, path, rule.Operations))
+		}
+	}
+	return warnings
+}
+
+// hasWildcardOperation returns true if the operations include the wildcard
+// operation.
+func hasWildcardOperation(operations []admissionregistration.OperationType) bool {
+	for _, op := range operations {
+		if op == admissionregistration.OperationAll {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDeleteOperation returns true if the operations include DELETE or the
+// wildcard operation.
+func hasDeleteOperation(operations []admissionregistration.OperationType) bool {
+	for _, op := range operations {
+		if op == admissionregistration.Delete || op == admissionregistration.OperationAll {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDryRunOperation returns true if the operations include CREATE, UPDATE
+// or the wildcard operation.
+func hasDryRunOperation(operations []admissionregistration.OperationType) bool {
+	for _, op := range operations {
+		switch op {
+		case admissionregistration.Create, admissionregistration.Update, admissionregistration.OperationAll:
+			return true
+		}
+	}
+	return false
+}
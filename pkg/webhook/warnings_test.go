@@ -0,0 +1,265 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestValidateRuleWildcards(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.Rules[0].Resources = []string{"*", "pods"}
+	if err := wh.Validate(); err == nil {
+		t.Error("expected mixing '*' with explicit resources to fail validation")
+	}
+
+	wh.Rules[0].Resources = []string{"*"}
+	if err := wh.Validate(); err != nil {
+		t.Errorf("expected a pure wildcard resource rule to be valid, got %v", err)
+	}
+
+	// mixing '*' with explicit operations is redundant but not an error
+	wh.Rules[0].Operations = []admissionregistration.OperationType{
+		admissionregistration.OperationAll,
+		admissionregistration.Create,
+	}
+	if err := wh.Validate(); err != nil {
+		t.Errorf("expected redundant operations to not fail validation, got %v", err)
+	}
+	// the wildcard operation also triggers the DELETE warning; suppress it
+	// to assert on the redundancy warning alone
+	o := NewGenerator(WithSuppressDeleteWarning(true), WithWebhook(wh))
+	if warnings := o.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning for redundant operations, got %v", warnings)
+	}
+}
+
+func TestValidateRuleResources(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	for _, resources := range [][]string{
+		{"pods/status"},
+		{"*/scale"},
+		{"pods"},
+	} {
+		wh.Rules[0].Resources = resources
+		if err := wh.Validate(); err != nil {
+			t.Errorf("expected resources %v to be valid, got %v", resources, err)
+		}
+	}
+	for _, resources := range [][]string{
+		{""},
+		{"pods/"},
+		{"/status"},
+		{"pods/status/extra"},
+	} {
+		wh.Rules[0].Resources = resources
+		if err := wh.Validate(); err == nil {
+			t.Errorf("expected resources %v to fail validation", resources)
+		}
+	}
+}
+
+func TestWarningsUnknownSubresource(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.Rules[0].Resources = []string{"pods/stauts"}
+
+	o := NewGenerator(WithWebhook(wh))
+	if warnings := o.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning for a misspelled subresource, got %v", warnings)
+	}
+
+	// Known subresources and wildcard subresources are not flagged.
+	for _, resources := range [][]string{
+		{"pods/status"},
+		{"*/scale"},
+		{"pods/*"},
+		{"pods"},
+	} {
+		wh.Rules[0].Resources = resources
+		if warnings := o.Warnings(); len(warnings) != 0 {
+			t.Errorf("expected no warnings for resources %v, got %v", resources, warnings)
+		}
+	}
+}
+
+func TestRuleWithSubresource(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	rule := RuleWithSubresource(wh.Rules[0], "status")
+	if len(rule.Resources) != 1 || rule.Resources[0] != "deployments/status" {
+		t.Errorf("expected the subresource to be appended, got %v", rule.Resources)
+	}
+	// the original rule is untouched
+	if wh.Rules[0].Resources[0] != "deployments" {
+		t.Errorf("expected the original rule to be unchanged, got %v", wh.Rules[0].Resources)
+	}
+	// entries already naming a subresource are left alone
+	again := RuleWithSubresource(rule, "scale")
+	if again.Resources[0] != "deployments/status" {
+		t.Errorf("expected the existing subresource to be kept, got %v", again.Resources)
+	}
+}
+
+func TestWarningsSideEffects(t *testing.T) {
+	someSideEffects := admissionregistration.SideEffectClassSome
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.SideEffects = &someSideEffects
+
+	// the DELETE warning is suppressed so the assertions below only see the
+	// side-effect warnings
+	o := NewGenerator(WithSuppressDeleteWarning(true), WithWebhook(wh))
+	warnings := o.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning for sideEffects: Some on CREATE/UPDATE, got %v", warnings)
+	}
+
+	// NoneOnDryRun is dry-run safe and should not warn.
+	noneOnDryRun := admissionregistration.SideEffectClassNoneOnDryRun
+	wh.SideEffects = &noneOnDryRun
+	if warnings := o.Warnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings for NoneOnDryRun, got %v", warnings)
+	}
+
+	// DELETE-only webhooks are not flagged.
+	wh.SideEffects = &someSideEffects
+	wh.Rules[0].Operations = []admissionregistration.OperationType{admissionregistration.Delete}
+	if warnings := o.Warnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings for DELETE-only rules, got %v", warnings)
+	}
+}
+
+func TestSelfInterceptionWarnings(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	wh := testMutatingWebhook("mutatedeployments.example.com", "/mutate-deployments")
+	o := NewGenerator(WithWebhook(wh))
+
+	warnings := o.SelfInterceptionWarnings([]schema.GroupVersionResource{deployments})
+	if len(warnings) != 1 {
+		t.Fatalf("expected one self-interception warning, got %v", warnings)
+	}
+
+	// A namespace selector is assumed to exclude the controller's namespace.
+	wh.NamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"webhooks": "enabled"},
+	}
+	if warnings := o.SelfInterceptionWarnings([]schema.GroupVersionResource{deployments}); len(warnings) != 0 {
+		t.Errorf("expected no warnings with a namespace selector, got %v", warnings)
+	}
+
+	// A GVR the webhook doesn't match is not flagged.
+	wh.NamespaceSelector = nil
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	if warnings := o.SelfInterceptionWarnings([]schema.GroupVersionResource{pods}); len(warnings) != 0 {
+		t.Errorf("expected no warnings for an unmatched resource, got %v", warnings)
+	}
+}
+
+func TestSelfInterceptionWarningsWildcard(t *testing.T) {
+	wh := testMutatingWebhook("mutateall.example.com", "/mutate-all")
+	wh.Rules[0].APIGroups = []string{"*"}
+	wh.Rules[0].Resources = []string{"*"}
+	wh.Rules[0].APIVersions = []string{"*"}
+
+	o := NewGenerator(WithWebhook(wh))
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	if warnings := o.SelfInterceptionWarnings([]schema.GroupVersionResource{gvr}); len(warnings) != 1 {
+		t.Errorf("expected a warning for wildcard rules, got %v", warnings)
+	}
+}
+
+func TestWarningsDeleteOperation(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	wh.Rules[0].Operations = []admissionregistration.OperationType{admissionregistration.Delete}
+
+	o := NewGenerator(WithWebhook(wh))
+	if warnings := o.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning for a DELETE rule, got %v", warnings)
+	}
+
+	// the wildcard operation includes DELETE
+	wh.Rules[0].Operations = []admissionregistration.OperationType{admissionregistration.OperationAll}
+	if warnings := o.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning for a wildcard rule, got %v", warnings)
+	}
+
+	// suppressible for servers known to handle oldObject
+	o = NewGenerator(WithSuppressDeleteWarning(true), WithWebhook(wh))
+	if warnings := o.Warnings(); len(warnings) != 0 {
+		t.Errorf("expected the warning to be suppressed, got %v", warnings)
+	}
+
+	// CREATE-only webhooks are not flagged
+	wh.Rules[0].Operations = []admissionregistration.OperationType{admissionregistration.Create}
+	o = NewGenerator(WithWebhook(wh))
+	if warnings := o.Warnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings for CREATE-only rules, got %v", warnings)
+	}
+}
+
+func TestDeprecationWarningAndAnnotations(t *testing.T) {
+	wh := testMutatingWebhook("mutatepods.example.com", "/mutate-pods")
+	wh.Deprecated = true
+	wh.DeprecationMessage = "replaced by the pod defaulting policy, removal in v2"
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(wh),
+	)
+
+	var warning string
+	for _, w := range o.Warnings() {
+		if strings.Contains(w, "is deprecated") {
+			warning = w
+		}
+	}
+	if len(warning) == 0 {
+		t.Fatalf("expected a deprecation warning, got %v", o.Warnings())
+	}
+	if !strings.Contains(warning, "replaced by the pod defaulting policy") {
+		t.Errorf("expected the warning to carry the deprecation message, got %q", warning)
+	}
+
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	annotations := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Annotations
+	if annotations["webhook.kubernetes.io/deprecated"] != "true" {
+		t.Errorf("expected the deprecated annotation, got %v", annotations)
+	}
+	if !strings.Contains(annotations["webhook.kubernetes.io/deprecation-message"], "00-mutatepods.example.com") {
+		t.Errorf("expected the message annotation to name the webhook, got %v", annotations)
+	}
+}
+
+func TestNoDeprecationAnnotationsByDefault(t *testing.T) {
+	o := NewGenerator(
+		WithHost("localhost"),
+		WithWebhook(testMutatingWebhook("mutatepods.example.com", "/mutate-pods")),
+	)
+	configs, err := o.whConfigs()
+	if err != nil {
+		t.Fatalf("expected generation to succeed, got %v", err)
+	}
+	annotations := configs[0].(*admissionregistration.MutatingWebhookConfiguration).Annotations
+	if _, found := annotations["webhook.kubernetes.io/deprecated"]; found {
+		t.Errorf("expected no deprecation annotation without deprecated webhooks, got %v", annotations)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounceInterval is how long WatchConfig waits after a filesystem event
+// before invoking the regenerate function, so that rapid successive saves
+// are coalesced into a single regeneration.
+const debounceInterval = 200 * time.Millisecond
+
+// WatchConfig watches the config file at configPath and calls regenerateFn
+// whenever the file changes. Rapid successive changes are coalesced, so a
+// burst of writes triggers regenerateFn only once.
+// It blocks until the context is canceled, regenerateFn returns an error, or
+// watching fails.
+func WatchConfig(ctx context.Context, configPath string, regenerateFn func() error) error {
+	return WatchConfigWithDebounce(ctx, configPath, debounceInterval, regenerateFn)
+}
+
+// WatchConfigWithDebounce is like WatchConfig with a caller-chosen quiet
+// period instead of the default.
+func WatchConfigWithDebounce(ctx context.Context, configPath string, quietPeriod time.Duration, regenerateFn func() error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close() // nolint: errcheck
+
+	if err := watcher.Add(configPath); err != nil {
+		return err
+	}
+
+	// The timer is created stopped and armed on the first relevant event.
+	debounce := time.NewTimer(quietPeriod)
+	if !debounce.Stop() {
+		<-debounce.C
+	}
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if !debounce.Stop() {
+				select {
+				case <-debounce.C:
+				default:
+				}
+			}
+			debounce.Reset(quietPeriod)
+		case <-debounce.C:
+			if err := regenerateFn(); err != nil {
+				return err
+			}
+		case err := <-watcher.Errors:
+			return err
+		}
+	}
+}
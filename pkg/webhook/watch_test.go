@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchConfigDebouncesRapidWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-watch")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	configPath := filepath.Join(dir, "webhook-config.yaml")
+	if err := ioutil.WriteFile(configPath, []byte("version: 0"), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	var calls int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		done <- WatchConfig(ctx, configPath, func() error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		})
+	}()
+
+	// Give the watcher a moment to be established.
+	time.Sleep(100 * time.Millisecond)
+
+	// Two rapid writes should be coalesced into a single regeneration.
+	if err := ioutil.WriteFile(configPath, []byte("version: 1"), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := ioutil.WriteFile(configPath, []byte("version: 2"), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	time.Sleep(3 * debounceInterval)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected regenerateFn to be called once, got %d", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected WatchConfig to return nil on cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,490 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// webhookType is the type of an admission webhook.
+type webhookType int
+
+const (
+	_ webhookType = iota
+	webhookTypeMutating
+	webhookTypeValidating
+	// webhookTypeBoth is for a webhook that does defaulting and validation
+	// at the same endpoint. It emits an entry in both the
+	// MutatingWebhookConfiguration and the ValidatingWebhookConfiguration.
+	webhookTypeBoth
+)
+
+// String returns the lower-case name of the webhook type.
+func (t webhookType) String() string {
+	switch t {
+	case webhookTypeMutating:
+		return "mutating"
+	case webhookTypeValidating:
+		return "validating"
+	case webhookTypeBoth:
+		return "both"
+	default:
+		return "unknown"
+	}
+}
+
+// matches returns true if a webhook of type t should emit an entry in the
+// configuration for type target.
+func (t webhookType) matches(target webhookType) bool {
+	return t == target || t == webhookTypeBoth
+}
+
+// admissionWebhook describes an admission webhook that configuration should
+// be generated for.
+type admissionWebhook struct {
+	// Name is the name of the webhook.
+	// It must be fully qualified, e.g. checkpods.example.com.
+	Name string
+	// Type is the webhook type, i.e. mutating, validating.
+	Type webhookType
+	// Path is the path that the webhook is served under.
+	// It is used as the key in the generator registry, so it must be unique
+	// among all registered webhooks.
+	Path string
+	// Order controls where the webhook fires in the mutating webhook chain.
+	// Kubernetes fires mutating webhooks in alphabetical name order, so the
+	// name is prefixed with the zero-padded order (e.g. 00-, 01-) during
+	// defaulting. Lower values fire first.
+	Order int
+	// Enabled toggles the webhook for gradual rollout. When explicitly
+	// false, the webhook stays in the registry but is skipped during
+	// generation, so re-enabling it is a single-field change.
+	// Defaults to enabled if unset.
+	Enabled *bool
+	// Deprecated marks the webhook as being phased out. A deprecated webhook
+	// still generates its configuration entry, but Warnings reports it and
+	// the generated configuration carries deprecation annotations, so the
+	// phase-out is visible without removing the webhook.
+	Deprecated bool
+	// DeprecationMessage is a human-readable note about the deprecation,
+	// e.g. what replaces the webhook and when it goes away.
+	DeprecationMessage string
+	// Labels are free-form labels declared on the webhook itself. They do
+	// not map to the generated API objects (webhook entries have no
+	// labels); they only influence which webhooks GenerateFiltered emits,
+	// e.g. for generating a canary subset for a staged rollout.
+	Labels map[string]string
+	// Rules describe what operations on what resources/subresources the
+	// webhook cares about.
+	Rules []admissionregistration.RuleWithOperations
+	// Scope restricts the rules of the webhook to cluster-scoped resources,
+	// namespaced resources, or both. When set, it is applied to all rules
+	// during generation. The scope field requires Kubernetes 1.14+.
+	// If unset, all scopes match, i.e. "*".
+	Scope *admissionregistration.ScopeType
+	// ServePath, when set, is used as the clientConfig path instead of Path,
+	// for deployments where a proxy serves the webhook under a different
+	// external path than the in-process mux. Path stays the registry key and
+	// internal routing path. Must start with /.
+	ServePath *string
+	// URL, when set, overrides the host/service configured on the generator
+	// options for this webhook: its clientConfig points at the given https
+	// URL instead. This allows mixing in-cluster webhooks and webhooks
+	// served at an external fixed URL in one generated config.
+	URL *string
+	// FailurePolicy defines how unrecognized errors from the admission
+	// endpoint are handled. Defaults to Ignore if unset.
+	FailurePolicy *admissionregistration.FailurePolicyType
+	// FailurePolicyPerOperation overrides FailurePolicy for individual
+	// operations, e.g. Fail on CREATE but Ignore on DELETE. Since the API
+	// only supports one failure policy per webhook entry, the webhook is
+	// expanded into one entry per distinct policy during generation.
+	// Operations not listed here keep FailurePolicy under the original name.
+	FailurePolicyPerOperation map[admissionregistration.OperationType]admissionregistration.FailurePolicyType
+	// ReinvocationPolicy states whether the webhook may be called again after
+	// a later mutating webhook changes the object. Only meaningful for
+	// mutating webhooks; ignored for validating webhooks. If unset, the
+	// generator-wide default applies.
+	ReinvocationPolicy *admissionregistration.ReinvocationPolicyType
+	// MaxRequestBodyBytes caps the size of admission request bodies the
+	// webhook server accepts for this webhook, protecting it from OOM on
+	// oversized objects. It is enforced by the LimitRequestBody middleware,
+	// not by the generated configuration. Defaults to 10MB if unset.
+	MaxRequestBodyBytes int64
+	// RateLimit, when set, caps the request rate the webhook handler
+	// accepts. It is enforced by the LimitRequestRate middleware, not by the
+	// generated configuration.
+	RateLimit *RateLimitConfig
+	// BypassAnnotationKey and BypassAnnotationValue exclude objects that
+	// carry the given key/value from the webhook via an objectSelector NotIn
+	// requirement, so objects the webhook server creates itself (e.g. a
+	// ConfigMap it uses for state) don't recurse through it. Note that
+	// objectSelector evaluates labels, so the key/value must be mirrored
+	// into the labels of the objects to bypass. Both must be set together.
+	// Requires Kubernetes 1.15+ (objectSelector).
+	BypassAnnotationKey   string
+	BypassAnnotationValue string
+	// NamespaceSelector decides whether to run the webhook on an object
+	// based on the labels of its namespace.
+	NamespaceSelector *metav1.LabelSelector
+	// ObjectSelector decides whether to run the webhook based on the labels
+	// of the object itself. It ANDs with NamespaceSelector at the API level.
+	// Requires Kubernetes 1.15+.
+	ObjectSelector *metav1.LabelSelector
+	// SideEffects states whether the webhook has side effects.
+	// Defaults to Unknown if unset.
+	SideEffects *admissionregistration.SideEffectClass
+	// MatchConditions are CEL expressions that pre-filter requests before
+	// they are sent to the webhook. Match conditions require Kubernetes
+	// 1.28+ and are only populated when generating configuration for the
+	// v1 admissionregistration API; they are skipped for v1beta1 to avoid
+	// schema errors.
+	MatchConditions []admissionregistrationv1.MatchCondition
+	// Validations are CEL expressions expressing the webhook's validation
+	// logic. When set on a validating webhook, ValidatingAdmissionPolicies
+	// can emit a ValidatingAdmissionPolicy/Binding pair for the webhook
+	// instead of a webhook entry, so the validation runs in-process in the
+	// API server. Only meaningful for validating webhooks.
+	Validations []admissionregistrationv1.Validation
+}
+
+// WithScopeSelectors sets the namespaceSelector and the objectSelector from
+// plain label maps in one call, for the common "in namespaces labeled X AND
+// objects labeled Y" shape. The two selectors AND at the API level. A nil or
+// empty map leaves the corresponding selector untouched. The labels are
+// checked by Validate. Returns the webhook for chaining.
+func (w *admissionWebhook) WithScopeSelectors(nsLabels, objLabels map[string]string) *admissionWebhook {
+	if len(nsLabels) != 0 {
+		w.NamespaceSelector = &metav1.LabelSelector{MatchLabels: nsLabels}
+	}
+	if len(objLabels) != 0 {
+		w.ObjectSelector = &metav1.LabelSelector{MatchLabels: objLabels}
+	}
+	return w
+}
+
+// Validate validates if the webhook is valid.
+func (w *admissionWebhook) Validate() error {
+	if len(w.Name) == 0 {
+		return fmt.Errorf("webhook should have a name")
+	}
+	if w.Type != webhookTypeMutating && w.Type != webhookTypeValidating && w.Type != webhookTypeBoth {
+		return fmt.Errorf("webhook %q should have a valid type", w.Name)
+	}
+	if len(w.Path) == 0 || !strings.HasPrefix(w.Path, "/") {
+		return fmt.Errorf("webhook %q should have a path starting with /", w.Name)
+	}
+	if w.ServePath != nil && !strings.HasPrefix(*w.ServePath, "/") {
+		return fmt.Errorf("webhook %q should have a serve path starting with /, got %q", w.Name, *w.ServePath)
+	}
+	if (len(w.BypassAnnotationKey) == 0) != (len(w.BypassAnnotationValue) == 0) {
+		return fmt.Errorf("webhook %q should set the bypass annotation key and value together", w.Name)
+	}
+	if w.RateLimit != nil && (w.RateLimit.Rate <= 0 || w.RateLimit.Burst <= 0) {
+		return fmt.Errorf("webhook %q should have a positive rate limit rate and burst, got rate %v burst %d", w.Name, w.RateLimit.Rate, w.RateLimit.Burst)
+	}
+	if err := w.validateSelectorLabels(); err != nil {
+		return err
+	}
+	if len(w.Rules) == 0 {
+		return fmt.Errorf("webhook %q should have at least one rule", w.Name)
+	}
+	if err := w.ValidateRuleWildcards(); err != nil {
+		return err
+	}
+	if err := w.validateRuleResources(); err != nil {
+		return err
+	}
+	if w.URL != nil {
+		u, err := url.Parse(*w.URL)
+		if err != nil {
+			return fmt.Errorf("webhook %q has invalid URL: %v", w.Name, err)
+		}
+		if u.Scheme != "https" {
+			return fmt.Errorf("webhook %q URL should use the https scheme, got %q", w.Name, *w.URL)
+		}
+		if len(u.Host) == 0 {
+			return fmt.Errorf("webhook %q URL should have a host, got %q", w.Name, *w.URL)
+		}
+		if u.User != nil || len(u.RawQuery) != 0 || len(u.Fragment) != 0 {
+			return fmt.Errorf("webhook %q URL should not contain user info, query parameters or fragments, got %q", w.Name, *w.URL)
+		}
+	}
+	conditionNames := map[string]bool{}
+	for _, condition := range w.MatchConditions {
+		if len(condition.Name) == 0 {
+			return fmt.Errorf("webhook %q has a match condition without a name", w.Name)
+		}
+		if len(condition.Expression) == 0 {
+			return fmt.Errorf("webhook %q match condition %q has an empty expression", w.Name, condition.Name)
+		}
+		if conditionNames[condition.Name] {
+			return fmt.Errorf("webhook %q has duplicate match condition name %q", w.Name, condition.Name)
+		}
+		conditionNames[condition.Name] = true
+	}
+	if w.Scope != nil {
+		switch *w.Scope {
+		case admissionregistration.ClusterScope, admissionregistration.NamespacedScope, admissionregistration.AllScopes:
+		default:
+			return fmt.Errorf("webhook %q has invalid scope %q, should be Cluster, Namespaced or *", w.Name, *w.Scope)
+		}
+	}
+	if w.ReinvocationPolicy != nil {
+		if err := validateReinvocationPolicy(*w.ReinvocationPolicy); err != nil {
+			return fmt.Errorf("webhook %q: %v", w.Name, err)
+		}
+	}
+	if len(w.Validations) != 0 {
+		if !w.Type.matches(webhookTypeValidating) {
+			return fmt.Errorf("webhook %q sets validations, which only apply to validating webhooks", w.Name)
+		}
+		for _, validation := range w.Validations {
+			if len(validation.Expression) == 0 {
+				return fmt.Errorf("webhook %q has a validation with an empty expression", w.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateReinvocationPolicy rejects reinvocation policies other than Never
+// and IfNeeded.
+func validateReinvocationPolicy(policy admissionregistration.ReinvocationPolicyType) error {
+	switch policy {
+	case admissionregistration.NeverReinvocationPolicy, admissionregistration.IfNeededReinvocationPolicy:
+		return nil
+	}
+	return fmt.Errorf("invalid reinvocationPolicy %q, should be Never or IfNeeded", policy)
+}
+
+// overlapsWith returns true if the two webhooks share at least one resource
+// and one operation in their rules, i.e. some request could match both.
+func (w *admissionWebhook) overlapsWith(other *admissionWebhook) bool {
+	for _, rule := range w.Rules {
+		for _, otherRule := range other.Rules {
+			if stringsIntersect(rule.Resources, otherRule.Resources) &&
+				operationsIntersect(rule.Operations, otherRule.Operations) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringsIntersect returns true if the two slices share an element or either
+// contains the wildcard '*'.
+func stringsIntersect(a, b []string) bool {
+	if containsWildcard(a) || containsWildcard(b) {
+		return true
+	}
+	for _, v := range a {
+		for _, w := range b {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// operationsIntersect returns true if the two operation lists share an
+// operation or either contains the wildcard operation.
+func operationsIntersect(a, b []admissionregistration.OperationType) bool {
+	if hasWildcardOperation(a) || hasWildcardOperation(b) {
+		return true
+	}
+	for _, v := range a {
+		for _, w := range b {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithEnabled returns a webhook option that enables or disables the webhook.
+func WithEnabled(enabled bool) func(*admissionWebhook) {
+	return func(w *admissionWebhook) {
+		w.Enabled = &enabled
+	}
+}
+
+// disabled returns true if the webhook is explicitly disabled.
+func (w *admissionWebhook) disabled() bool {
+	return w.Enabled != nil && !*w.Enabled
+}
+
+// ValidateRuleWildcards checks that no rule mixes the wildcard resource '*'
+// with explicit resources in the same rule entry. The API server rejects
+// such rules because the semantics are ambiguous.
+func (w *admissionWebhook) ValidateRuleWildcards() error {
+	for _, rule := range w.Rules {
+		if len(rule.Resources) > 1 && containsWildcard(rule.Resources) {
+			return fmt.Errorf("webhook %q mixes the wildcard resource '*' with explicit resources %v in one rule", w.Name, rule.Resources)
+		}
+	}
+	return nil
+}
+
+// validateSelectorLabels checks the matchLabels of the namespace and object
+// selectors against the label key and value syntax rules.
+func (w *admissionWebhook) validateSelectorLabels() error {
+	for _, selector := range []*metav1.LabelSelector{w.NamespaceSelector, w.ObjectSelector} {
+		if selector == nil {
+			continue
+		}
+		for key, value := range selector.MatchLabels {
+			if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+				return fmt.Errorf("webhook %q has invalid selector label key %q: %s", w.Name, key, strings.Join(msgs, ", "))
+			}
+			if msgs := validation.IsValidLabelValue(value); len(msgs) != 0 {
+				return fmt.Errorf("webhook %q has invalid selector label value %q: %s", w.Name, value, strings.Join(msgs, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// validateRuleResources checks that every resource entry of every rule is
+// either a resource or a resource/subresource pair, e.g. pods, pods/status
+// or */scale. Both halves may be the wildcard '*'.
+func (w *admissionWebhook) validateRuleResources() error {
+	for _, rule := range w.Rules {
+		for _, resource := range rule.Resources {
+			if len(resource) == 0 {
+				return fmt.Errorf("webhook %q has an empty resource entry", w.Name)
+			}
+			parts := strings.Split(resource, "/")
+			if len(parts) > 2 {
+				return fmt.Errorf("webhook %q has invalid resource %q, should be resource or resource/subresource", w.Name, resource)
+			}
+			if len(parts) == 2 && (len(parts[0]) == 0 || len(parts[1]) == 0) {
+				return fmt.Errorf("webhook %q has invalid resource %q, both sides of the / must be non-empty", w.Name, resource)
+			}
+		}
+	}
+	return nil
+}
+
+// containsWildcard returns true if values contains the wildcard '*'.
+func containsWildcard(values []string) bool {
+	for _, v := range values {
+		if v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// expandFailurePolicies expands the webhook into one entry per distinct
+// per-operation failure policy. Operations without an explicit per-operation
+// policy stay on an entry with the original name and failure policy; each
+// overridden policy gets an entry with the policy appended to the name (e.g.
+// checkpods.example.com.fail), so the generated names are unique and
+// deterministic. Webhooks without per-operation policies expand to themselves.
+func (w *admissionWebhook) expandFailurePolicies() []*admissionWebhook {
+	if len(w.FailurePolicyPerOperation) == 0 {
+		return []*admissionWebhook{w}
+	}
+
+	var expanded []*admissionWebhook
+	// Operations keeping the default policy stay under the original name.
+	defaultRules := filterRuleOperations(w.Rules, func(op admissionregistration.OperationType) bool {
+		_, overridden := w.FailurePolicyPerOperation[op]
+		return !overridden
+	})
+	if len(defaultRules) != 0 {
+		cp := *w
+		cp.Rules = defaultRules
+		cp.FailurePolicyPerOperation = nil
+		expanded = append(expanded, &cp)
+	}
+
+	for _, policy := range sortedFailurePolicies(w.FailurePolicyPerOperation) {
+		policy := policy
+		rules := filterRuleOperations(w.Rules, func(op admissionregistration.OperationType) bool {
+			return w.FailurePolicyPerOperation[op] == policy
+		})
+		if len(rules) == 0 {
+			continue
+		}
+		cp := *w
+		cp.Name = fmt.Sprintf("%s.%s", w.Name, strings.ToLower(string(policy)))
+		cp.Rules = rules
+		cp.FailurePolicy = &policy
+		cp.FailurePolicyPerOperation = nil
+		expanded = append(expanded, &cp)
+	}
+	return expanded
+}
+
+// sortedFailurePolicies returns the distinct failure policies in the map in
+// deterministic order.
+func sortedFailurePolicies(policies map[admissionregistration.OperationType]admissionregistration.FailurePolicyType) []admissionregistration.FailurePolicyType {
+	seen := map[admissionregistration.FailurePolicyType]bool{}
+	var sorted []admissionregistration.FailurePolicyType
+	for _, policy := range policies {
+		if !seen[policy] {
+			seen[policy] = true
+			sorted = append(sorted, policy)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// filterRuleOperations copies the rules keeping only the operations accepted
+// by keep. Rules left without operations are dropped.
+func filterRuleOperations(rules []admissionregistration.RuleWithOperations, keep func(admissionregistration.OperationType) bool) []admissionregistration.RuleWithOperations {
+	var filtered []admissionregistration.RuleWithOperations
+	for i := range rules {
+		var operations []admissionregistration.OperationType
+		for _, op := range rules[i].Operations {
+			if keep(op) {
+				operations = append(operations, op)
+			}
+		}
+		if len(operations) == 0 {
+			continue
+		}
+		rule := admissionregistration.RuleWithOperations{}
+		rules[i].DeepCopyInto(&rule)
+		rule.Operations = operations
+		filtered = append(filtered, rule)
+	}
+	return filtered
+}
+
+// nameFor returns the name to use for the webhook entry in the configuration
+// for type target. For a webhook of type both, the target type is prepended
+// so the mutating and validating entries don't share a name.
+func (w *admissionWebhook) nameFor(target webhookType) string {
+	if w.Type != webhookTypeBoth {
+		return w.Name
+	}
+	return target.String() + "." + w.Name
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+)
+
+func TestExpandFailurePolicies(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	wh.Rules[0].Operations = []admissionregistration.OperationType{
+		admissionregistration.Create,
+		admissionregistration.Update,
+		admissionregistration.Delete,
+	}
+	wh.FailurePolicyPerOperation = map[admissionregistration.OperationType]admissionregistration.FailurePolicyType{
+		admissionregistration.Create: admissionregistration.Fail,
+		admissionregistration.Delete: admissionregistration.Ignore,
+	}
+
+	expanded := wh.expandFailurePolicies()
+	if len(expanded) != 3 {
+		t.Fatalf("expected 3 expanded webhooks, got %d", len(expanded))
+	}
+	// UPDATE has no override and keeps the original name and policy.
+	if expanded[0].Name != "validatepods.example.com" {
+		t.Errorf("expected the default entry to keep its name, got %q", expanded[0].Name)
+	}
+	if len(expanded[0].Rules[0].Operations) != 1 || expanded[0].Rules[0].Operations[0] != admissionregistration.Update {
+		t.Errorf("expected the default entry to keep UPDATE, got %v", expanded[0].Rules[0].Operations)
+	}
+	// Overridden policies are emitted in deterministic order: Fail, Ignore.
+	if expanded[1].Name != "validatepods.example.com.fail" ||
+		expanded[1].FailurePolicy == nil || *expanded[1].FailurePolicy != admissionregistration.Fail {
+		t.Errorf("unexpected Fail entry: %q %v", expanded[1].Name, expanded[1].FailurePolicy)
+	}
+	if len(expanded[1].Rules[0].Operations) != 1 || expanded[1].Rules[0].Operations[0] != admissionregistration.Create {
+		t.Errorf("expected the Fail entry to cover CREATE, got %v", expanded[1].Rules[0].Operations)
+	}
+	if expanded[2].Name != "validatepods.example.com.ignore" ||
+		expanded[2].FailurePolicy == nil || *expanded[2].FailurePolicy != admissionregistration.Ignore {
+		t.Errorf("unexpected Ignore entry: %q %v", expanded[2].Name, expanded[2].FailurePolicy)
+	}
+}
+
+func TestExpandFailurePoliciesNoOverride(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	expanded := wh.expandFailurePolicies()
+	if len(expanded) != 1 || expanded[0] != wh {
+		t.Errorf("expected the webhook to expand to itself, got %v", expanded)
+	}
+}
+
+func TestGenerateExpandedFailurePolicies(t *testing.T) {
+	wh := testValidatingWebhook("validatepods.example.com", "/validate-pods")
+	wh.Rules[0].Operations = []admissionregistration.OperationType{
+		admissionregistration.Create,
+		admissionregistration.Delete,
+	}
+	wh.FailurePolicyPerOperation = map[admissionregistration.OperationType]admissionregistration.FailurePolicyType{
+		admissionregistration.Create: admissionregistration.Fail,
+		admissionregistration.Delete: admissionregistration.Ignore,
+	}
+
+	o := NewGenerator(WithWebhook(wh))
+	objects, err := o.Generate()
+	if err != nil {
+		t.Fatalf("expected Generate to succeed, got %v", err)
+	}
+	cfg := objects[0].(*admissionregistration.ValidatingWebhookConfiguration)
+	if len(cfg.Webhooks) != 2 {
+		t.Fatalf("expected 2 webhook entries, got %d", len(cfg.Webhooks))
+	}
+	names := map[string]bool{}
+	for _, entry := range cfg.Webhooks {
+		names[entry.Name] = true
+	}
+	if !names["validatepods.example.com.fail"] || !names["validatepods.example.com.ignore"] {
+		t.Errorf("unexpected entry names: %v", names)
+	}
+}
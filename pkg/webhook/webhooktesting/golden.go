@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooktesting provides test utilities for projects that generate
+// webhook configuration, so each downstream project doesn't reimplement
+// golden-file comparison for its generated manifests.
+package webhooktesting
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var update = flag.Bool("update", false, "update the golden files instead of comparing against them")
+
+// caBundlePlaceholder replaces CABundle values during serialization, so
+// goldens don't depend on the certificates of the machine generating them.
+const caBundlePlaceholder = "<ca-bundle>"
+
+// AssertGolden serializes the objects deterministically and compares them to
+// the golden files in goldenDir, one file per object. Run the tests with
+// -update to (re)write the golden files instead. CABundle values are
+// normalized to a placeholder so the files are reproducible across machines.
+func AssertGolden(t testing.TB, objs []runtime.Object, goldenDir string) {
+	t.Helper()
+	for i, obj := range objs {
+		content, err := marshalDeterministic(obj)
+		if err != nil {
+			t.Fatalf("unable to serialize object %d: %v", i, err)
+		}
+		path := filepath.Join(goldenDir, goldenFilename(i, obj))
+		if *update {
+			if err := os.MkdirAll(goldenDir, 0755); err != nil {
+				t.Fatalf("unable to create golden directory: %v", err)
+			}
+			if err := ioutil.WriteFile(path, content, 0644); err != nil {
+				t.Fatalf("unable to write golden file %s: %v", path, err)
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("unable to read golden file %s (re-run with -update to create it): %v", path, err)
+		}
+		if !bytes.Equal(expected, content) {
+			t.Errorf("object %d differs from golden file %s (re-run with -update to accept the change):\nwant:\n%s\ngot:\n%s",
+				i, path, expected, content)
+		}
+	}
+}
+
+// goldenFilename names the golden file of the i-th object after its position,
+// kind and name, so the files sort in generation order and renames show up
+// as file renames.
+func goldenFilename(i int, obj runtime.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if len(kind) == 0 {
+		kind = fmt.Sprintf("%T", obj)
+		kind = kind[strings.LastIndex(kind, ".")+1:]
+	}
+	return fmt.Sprintf("%02d-%s.yaml", i, strings.ToLower(kind))
+}
+
+// marshalDeterministic serializes the object as YAML with sorted keys and
+// normalized CABundle values.
+func marshalDeterministic(obj runtime.Object) ([]byte, error) {
+	content, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		return nil, err
+	}
+	normalizeCABundles(decoded)
+	// ghodss/yaml marshals maps with sorted keys, so the output is stable.
+	return yaml.Marshal(decoded)
+}
+
+// normalizeCABundles recursively replaces every non-empty caBundle value
+// with the placeholder.
+func normalizeCABundles(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if key == "caBundle" {
+				if s, ok := nested.(string); ok && len(s) != 0 {
+					typed[key] = caBundlePlaceholder
+				}
+				continue
+			}
+			normalizeCABundles(nested)
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			normalizeCABundles(nested)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooktesting
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testObjects() []runtime.Object {
+	return []runtime.Object{
+		&admissionregistration.MutatingWebhookConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "admissionregistration.k8s.io/v1beta1",
+				Kind:       "MutatingWebhookConfiguration",
+			},
+			ObjectMeta: metav1.ObjectMeta{Name: "mutating-webhook-configuration"},
+			Webhooks: []admissionregistration.Webhook{
+				{
+					Name: "mutatepods.example.com",
+					ClientConfig: admissionregistration.WebhookClientConfig{
+						CABundle: []byte("machine-specific-certificate"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAssertGoldenRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	// write the goldens, then compare against them
+	*update = true
+	AssertGolden(t, testObjects(), dir)
+	*update = false
+	AssertGolden(t, testObjects(), dir)
+}
+
+func TestAssertGoldenNormalizesCABundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatalf("expected TempDir to succeed, got %v", err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	objs := testObjects()
+	*update = true
+	AssertGolden(t, objs, dir)
+	*update = false
+
+	content, err := ioutil.ReadFile(dir + "/00-mutatingwebhookconfiguration.yaml")
+	if err != nil {
+		t.Fatalf("expected the golden file to exist, got %v", err)
+	}
+	if strings.Contains(string(content), "machine-specific-certificate") {
+		t.Error("expected the CABundle to be normalized out of the golden file")
+	}
+	if !strings.Contains(string(content), caBundlePlaceholder) {
+		t.Errorf("expected the CABundle placeholder in the golden file, got:\n%s", content)
+	}
+
+	// a differing CABundle still matches the golden
+	objs[0].(*admissionregistration.MutatingWebhookConfiguration).Webhooks[0].ClientConfig.CABundle = []byte("another-certificate")
+	AssertGolden(t, objs, dir)
+}
+
+func TestGoldenFilename(t *testing.T) {
+	objs := testObjects()
+	if name := goldenFilename(3, objs[0]); name != "03-mutatingwebhookconfiguration.yaml" {
+		t.Errorf("unexpected golden filename %q", name)
+	}
+}